@@ -4,10 +4,11 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"os/signal"
+	"syscall"
 
 	"github.com/suzuki-shunsuke/go-error-with-exit-code/ecerror"
 	"github.com/yuyaban/gitlab-comment/pkg/cmd"
+	"github.com/yuyaban/gitlab-comment/pkg/execute"
 )
 
 var (
@@ -24,7 +25,8 @@ func main() {
 }
 
 func core() error {
-	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	// the received signal is forwarded to the command run by exec
+	ctx, cancel := execute.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 	runner := cmd.Runner{
 		Stdin:  os.Stdin,
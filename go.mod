@@ -11,9 +11,9 @@ require (
 	github.com/stretchr/testify v1.8.1
 	github.com/suzuki-shunsuke/github-comment-metadata v0.1.0
 	github.com/suzuki-shunsuke/go-error-with-exit-code v1.0.0
-	github.com/suzuki-shunsuke/go-timeout v1.0.0
 	github.com/urfave/cli/v2 v2.24.3
 	github.com/xanzy/go-gitlab v0.80.0
+	golang.org/x/sys v0.5.0
 	golang.org/x/term v0.5.0
 	gopkg.in/yaml.v2 v2.4.0
 )
@@ -42,7 +42,6 @@ require (
 	golang.org/x/crypto v0.3.0 // indirect
 	golang.org/x/net v0.7.0 // indirect
 	golang.org/x/oauth2 v0.5.0 // indirect
-	golang.org/x/sys v0.5.0 // indirect
 	golang.org/x/time v0.3.0 // indirect
 	google.golang.org/appengine v1.6.7 // indirect
 	google.golang.org/protobuf v1.28.1 // indirect
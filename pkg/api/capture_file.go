@@ -0,0 +1,183 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+	"github.com/yuyaban/gitlab-comment/pkg/config"
+)
+
+const (
+	// defaultCaptureFileMaxSize is the default maximum size of the content of a captured file
+	defaultCaptureFileMaxSize = 64 << 10
+
+	missingIgnore = "ignore"
+	missingWarn   = "warn"
+	missingFail   = "fail"
+)
+
+// CapturedFile is the content of files produced by the command.
+type CapturedFile struct {
+	// Found is false if no file matches the path
+	Found bool
+	// Paths are paths of matched files. Contents of them are concatenated in this order
+	Paths   []string
+	Content string
+	// Size is the total size of the files in bytes
+	Size int64
+	// Truncated is true if the content is cut at max_size
+	Truncated bool
+}
+
+// String returns the content, so that {{.Files.<name>}} is rendered as the content.
+func (file *CapturedFile) String() string {
+	return file.Content
+}
+
+// appendCaptureFiles validates captureFiles and appends them to files.
+// A file which has the same name as an existing one is ignored if their settings are the same, otherwise an error is returned.
+func appendCaptureFiles(files, captureFiles []*config.ExecCaptureFile) ([]*config.ExecCaptureFile, error) {
+	for _, file := range captureFiles {
+		if file.Name == "" {
+			return nil, errors.New("capture_files[].name is required")
+		}
+		if file.Path == "" {
+			return nil, errors.New("capture_files[].path is required: " + file.Name)
+		}
+		switch file.Missing {
+		case "", missingIgnore, missingWarn, missingFail:
+		default:
+			return nil, fmt.Errorf(`capture_files[].missing must be either "ignore", "warn", or "fail": %s`, file.Missing)
+		}
+		dup := false
+		for _, f := range files {
+			if f.Name != file.Name {
+				continue
+			}
+			if *f != *file {
+				return nil, fmt.Errorf("capture_files.%s %w", file.Name, errRunSettingConflict)
+			}
+			dup = true
+			break
+		}
+		if !dup {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+// readCaptureFiles reads files produced by the command.
+// Relative paths are resolved from dir, which is the working directory of the command.
+// An error is returned if files whose missing policy is "fail" aren't found,
+// but all files are read so that the comment can be posted anyway.
+func readCaptureFiles(dir string, captureFiles []*config.ExecCaptureFile) (map[string]*CapturedFile, error) {
+	files := make(map[string]*CapturedFile, len(captureFiles))
+	var missing []string
+	for _, captureFile := range captureFiles {
+		file, err := readCaptureFile(dir, captureFile)
+		if err != nil {
+			logrus.WithError(err).WithField("name", captureFile.Name).Warn("read files of capture_files")
+		}
+		files[captureFile.Name] = file
+		if file.Found {
+			continue
+		}
+		switch captureFile.Missing {
+		case missingWarn:
+			logrus.WithFields(logrus.Fields{
+				"name": captureFile.Name,
+				"path": captureFile.Path,
+			}).Warn("no file matches the path of capture_files")
+		case missingFail:
+			missing = append(missing, captureFile.Name)
+		}
+	}
+	if len(missing) != 0 {
+		return files, errors.New("files of capture_files aren't found: " + strings.Join(missing, ", "))
+	}
+	return files, nil
+}
+
+func readCaptureFile(dir string, captureFile *config.ExecCaptureFile) (*CapturedFile, error) {
+	file := &CapturedFile{}
+	pattern := captureFile.Path
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(dir, pattern)
+	}
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return file, fmt.Errorf("invalid glob pattern: %w", err)
+	}
+	sort.Strings(paths)
+	maxSize := captureFile.MaxSize
+	if maxSize <= 0 {
+		maxSize = defaultCaptureFileMaxSize
+	}
+	buf := &strings.Builder{}
+	for _, p := range paths {
+		size, err := readFileLimited(buf, p, maxSize-buf.Len())
+		if err != nil {
+			logrus.WithError(err).WithField("path", p).Warn("read a file of capture_files")
+			continue
+		}
+		file.Found = true
+		file.Paths = append(file.Paths, p)
+		file.Size += size
+	}
+	file.Content = buf.String()
+	file.Truncated = file.Size > int64(buf.Len())
+	return file, nil
+}
+
+// readFileLimited writes the file's content up to limit bytes to w and returns the file size.
+// If the content is cut, it is cut at a UTF-8 character boundary.
+// Files other than regular files such as directories and FIFOs are skipped, because reading them may block.
+func readFileLimited(w io.Writer, p string, limit int) (int64, error) {
+	stat, err := os.Stat(p)
+	if err != nil {
+		return 0, fmt.Errorf("get the file information: %w", err)
+	}
+	if !stat.Mode().IsRegular() {
+		return 0, errors.New("the path isn't a regular file: " + p)
+	}
+	if limit <= 0 {
+		return stat.Size(), nil
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		return 0, fmt.Errorf("open a file: %w", err)
+	}
+	defer f.Close()
+	b, err := io.ReadAll(io.LimitReader(f, int64(limit)))
+	if err != nil {
+		return 0, fmt.Errorf("read a file: %w", err)
+	}
+	if int64(len(b)) < stat.Size() {
+		b = trimIncompleteRune(b)
+	}
+	if _, err := w.Write(b); err != nil {
+		return 0, fmt.Errorf("write the content of a file: %w", err)
+	}
+	return stat.Size(), nil
+}
+
+// trimIncompleteRune removes the last UTF-8 character if it is cut in the middle.
+func trimIncompleteRune(b []byte) []byte {
+	for i := len(b) - 1; i >= 0 && i >= len(b)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(b[i]) {
+			if !utf8.FullRune(b[i:]) {
+				return b[:i]
+			}
+			return b
+		}
+	}
+	return b
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yuyaban/gitlab-comment/pkg/config"
+)
+
+func Test_readCaptureFiles(t *testing.T) { //nolint:funlen
+	t.Parallel()
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"coverage.txt":     "total: 80%\n",
+		"reports/a.txt":    "aaa\n",
+		"reports/b.txt":    "bbb\n",
+		"reports/long.log": "0123456789",
+		"reports/utf8.md":  "あいう",
+	} {
+		p := filepath.Join(dir, name)
+		require.Nil(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		require.Nil(t, os.WriteFile(p, []byte(content), 0o600))
+	}
+	data := []struct {
+		title        string
+		captureFiles []*config.ExecCaptureFile
+		exp          map[string]string
+		truncated    []string
+		isErr        bool
+	}{
+		{
+			title: "read files",
+			captureFiles: []*config.ExecCaptureFile{
+				{Name: "coverage", Path: "coverage.txt"},
+				{Name: "reports", Path: "reports/*.txt"},
+				{Name: "long", Path: filepath.Join(dir, "reports/long.log"), MaxSize: 4},
+				{Name: "utf8", Path: "reports/utf8.md", MaxSize: 4},
+				{Name: "dir", Path: "reports"},
+				{Name: "missing", Path: "missing.txt", Missing: "warn"},
+			},
+			exp: map[string]string{
+				"coverage": "total: 80%\n",
+				"reports":  "aaa\nbbb\n",
+				"long":     "0123",
+				"utf8":     "あ",
+				"dir":      "",
+				"missing":  "",
+			},
+			truncated: []string{"long", "utf8"},
+		},
+		{
+			title: "missing file fails",
+			captureFiles: []*config.ExecCaptureFile{
+				{Name: "coverage", Path: "coverage.txt"},
+				{Name: "missing", Path: "missing.txt", Missing: "fail"},
+			},
+			exp: map[string]string{
+				"coverage": "total: 80%\n",
+				"missing":  "",
+			},
+			isErr: true,
+		},
+	}
+	for _, d := range data {
+		d := d
+		t.Run(d.title, func(t *testing.T) {
+			t.Parallel()
+			files, err := readCaptureFiles(dir, d.captureFiles)
+			if d.isErr {
+				require.NotNil(t, err)
+			} else {
+				require.Nil(t, err)
+			}
+			contents := make(map[string]string, len(files))
+			var truncated []string
+			for name, file := range files {
+				contents[name] = file.String()
+				require.Equal(t, name != "missing" && name != "dir", file.Found)
+				if file.Truncated {
+					truncated = append(truncated, name)
+				}
+			}
+			sort.Strings(truncated)
+			require.Equal(t, d.exp, contents)
+			require.Equal(t, d.truncated, truncated)
+		})
+	}
+}
@@ -0,0 +1,24 @@
+//go:build !windows
+
+package api
+
+import (
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yuyaban/gitlab-comment/pkg/config"
+)
+
+func Test_readCaptureFiles_fifo(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	require.Nil(t, syscall.Mkfifo(filepath.Join(dir, "out.fifo"), 0o600))
+	// a FIFO without writers would block forever if it were opened
+	files, err := readCaptureFiles(dir, []*config.ExecCaptureFile{
+		{Name: "fifo", Path: "*.fifo", Missing: "fail"},
+	})
+	require.NotNil(t, err)
+	require.False(t, files["fifo"].Found)
+}
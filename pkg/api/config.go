@@ -0,0 +1,84 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/yuyaban/gitlab-comment/pkg/config"
+	"github.com/yuyaban/gitlab-comment/pkg/option"
+)
+
+type ConfigDiscoverer interface {
+	Discover(cfgPath, wd string) []*config.Candidate
+}
+
+type ConfigController struct {
+	// Wd is a path to the working directory
+	Wd         string
+	Stdout     io.Writer
+	Stderr     io.Writer
+	Fsys       Fsys
+	Discoverer ConfigDiscoverer
+}
+
+// Which outputs every candidate of configuration files and whether it is used.
+func (ctrl *ConfigController) Which(opts *option.ConfigWhichOptions) error {
+	w := tabwriter.NewWriter(ctrl.Stdout, 0, 0, 2, ' ', 0) //nolint:gomnd
+	fmt.Fprintln(w, "USED\tLAYER\tPATH\tREASON")
+	for _, candidate := range ctrl.Discoverer.Discover(opts.ConfigPath, ctrl.Wd) {
+		used := ""
+		if candidate.Used {
+			used = "*"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", used, candidate.Layer, candidate.Path, candidate.Reason)
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("output configuration candidates: %w", err)
+	}
+	fmt.Fprintln(ctrl.Stdout, "\nUsed files are merged from top to bottom. Later files take precedence.")
+	return nil
+}
+
+// Migrate converts github-comment's configuration file to gitlab-comment's one.
+func (ctrl *ConfigController) Migrate(opts *option.ConfigMigrateOptions) error {
+	input := opts.Input
+	if input == "" {
+		for _, candidate := range ctrl.Discoverer.Discover("", ctrl.Wd) {
+			if candidate.Legacy {
+				input = candidate.Path
+				break
+			}
+		}
+		if input == "" {
+			return errors.New("github-comment's configuration file isn't found")
+		}
+	}
+	if opts.Output != "" && !opts.Force && ctrl.Fsys.Exist(opts.Output) {
+		return errors.New(opts.Output + " already exists. Use --force to overwrite it")
+	}
+	b, err := ctrl.Fsys.Read(input)
+	if err != nil {
+		return fmt.Errorf("read github-comment's configuration file %s: %w", input, err)
+	}
+	out, warnings, err := config.MigrateGitHubComment(b)
+	if err != nil {
+		return fmt.Errorf("migrate %s: %w", input, err)
+	}
+	for _, warning := range warnings {
+		fmt.Fprintln(ctrl.Stderr, "[WARN] "+warning)
+	}
+	out = append([]byte("# migrated from "+input+" by gitlab-comment config migrate\n"), out...)
+	if opts.Output == "" {
+		if _, err := ctrl.Stdout.Write(out); err != nil {
+			return fmt.Errorf("output the configuration: %w", err)
+		}
+		return nil
+	}
+	if err := ctrl.Fsys.Write(opts.Output, out); err != nil {
+		return fmt.Errorf("write the configuration to %s: %w", opts.Output, err)
+	}
+	fmt.Fprintln(ctrl.Stderr, "gitlab-comment's configuration file is written to "+opts.Output)
+	return nil
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yuyaban/gitlab-comment/pkg/config"
+	"github.com/yuyaban/gitlab-comment/pkg/option"
+)
+
+type memFsys struct {
+	files map[string][]byte
+}
+
+func (fsys *memFsys) Exist(p string) bool {
+	_, ok := fsys.files[p]
+	return ok
+}
+
+func (fsys *memFsys) Read(p string) ([]byte, error) {
+	b, ok := fsys.files[p]
+	if !ok {
+		return nil, errors.New(p + " isn't found")
+	}
+	return b, nil
+}
+
+func (fsys *memFsys) Write(p string, content []byte) error {
+	fsys.files[p] = content
+	return nil
+}
+
+type staticDiscoverer []*config.Candidate
+
+func (discoverer staticDiscoverer) Discover(cfgPath, wd string) []*config.Candidate {
+	return discoverer
+}
+
+func TestConfigController_Which(t *testing.T) {
+	t.Parallel()
+	data := []struct {
+		title      string
+		candidates staticDiscoverer
+		exp        string
+	}{
+		{
+			title: "used and ignored files",
+			candidates: staticDiscoverer{
+				{Path: "/etc/gitlab-comment/config.yaml", Layer: config.LayerSystem, Reason: "not found"},
+				{Path: "/repo/.gitlab-comment.yaml", Layer: config.LayerRepository, Exists: true, Used: true, Reason: "found"},
+			},
+			exp: `USED  LAYER       PATH                             REASON
+      system      /etc/gitlab-comment/config.yaml  not found
+*     repository  /repo/.gitlab-comment.yaml       found
+
+Used files are merged from top to bottom. Later files take precedence.
+`,
+		},
+	}
+	for _, d := range data {
+		d := d
+		t.Run(d.title, func(t *testing.T) {
+			t.Parallel()
+			stdout := &bytes.Buffer{}
+			ctrl := &ConfigController{
+				Stdout:     stdout,
+				Discoverer: d.candidates,
+			}
+			require.Nil(t, ctrl.Which(&option.ConfigWhichOptions{}))
+			require.Equal(t, d.exp, stdout.String())
+		})
+	}
+}
+
+func TestConfigController_Migrate(t *testing.T) { //nolint:funlen
+	t.Parallel()
+	data := []struct {
+		title  string
+		files  map[string][]byte
+		opts   *option.ConfigMigrateOptions
+		exp    map[string][]byte
+		stdout string
+		isErr  bool
+	}{
+		{
+			title: "discovered file is written to the standard output",
+			files: map[string][]byte{
+				"/repo/github-comment.yaml": []byte("post:\n  default: \"{{.PRNumber}}\"\n"),
+			},
+			opts:   &option.ConfigMigrateOptions{},
+			stdout: "# migrated from /repo/github-comment.yaml by gitlab-comment config migrate\npost:\n  default: '{{.MRNumber}}'\n",
+		},
+		{
+			title: "output already exists",
+			files: map[string][]byte{
+				"/repo/github-comment.yaml": []byte("post: {}\n"),
+				"/repo/gitlab-comment.yaml": []byte("post: {}\n"),
+			},
+			opts: &option.ConfigMigrateOptions{
+				Output: "/repo/gitlab-comment.yaml",
+			},
+			isErr: true,
+		},
+		{
+			title: "output is overwritten with --force",
+			files: map[string][]byte{
+				"/repo/github-comment.yaml": []byte("post: {}\n"),
+				"/repo/gitlab-comment.yaml": []byte("old"),
+			},
+			opts: &option.ConfigMigrateOptions{
+				Output: "/repo/gitlab-comment.yaml",
+				Force:  true,
+			},
+			exp: map[string][]byte{
+				"/repo/github-comment.yaml": []byte("post: {}\n"),
+				"/repo/gitlab-comment.yaml": []byte("# migrated from /repo/github-comment.yaml by gitlab-comment config migrate\npost: {}\n"),
+			},
+		},
+	}
+	for _, d := range data {
+		d := d
+		t.Run(d.title, func(t *testing.T) {
+			t.Parallel()
+			stdout := &bytes.Buffer{}
+			fsys := &memFsys{files: d.files}
+			ctrl := &ConfigController{
+				Wd:     "/repo",
+				Stdout: stdout,
+				Stderr: &bytes.Buffer{},
+				Fsys:   fsys,
+				Discoverer: staticDiscoverer{
+					{Path: "/repo/github-comment.yaml", Layer: config.LayerRepository, Exists: true, Legacy: true},
+				},
+			}
+			err := ctrl.Migrate(d.opts)
+			if d.isErr {
+				require.NotNil(t, err)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, d.stdout, stdout.String())
+			if d.exp != nil {
+				require.Equal(t, d.exp, fsys.files)
+			}
+		})
+	}
+}
@@ -5,24 +5,37 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/suzuki-shunsuke/go-error-with-exit-code/ecerror"
 	"github.com/yuyaban/gitlab-comment/pkg/config"
 	"github.com/yuyaban/gitlab-comment/pkg/execute"
 	"github.com/yuyaban/gitlab-comment/pkg/expr"
+	"github.com/yuyaban/gitlab-comment/pkg/findings"
 	"github.com/yuyaban/gitlab-comment/pkg/gitlab"
+	"github.com/yuyaban/gitlab-comment/pkg/junit"
 	"github.com/yuyaban/gitlab-comment/pkg/option"
 	"github.com/yuyaban/gitlab-comment/pkg/template"
+	"github.com/yuyaban/gitlab-comment/pkg/terraform"
+	"golang.org/x/term"
 )
 
+// cancelCommentTimeout is the maximum time to post a comment after the command is canceled.
+// CI services kill the job soon after they send SIGTERM
+const cancelCommentTimeout = 10 * time.Second
+
 type ExecController struct {
-	Wd       string
-	Stdin    io.Reader
-	Stdout   io.Writer
-	Stderr   io.Writer
-	Getenv   func(string) string
+	Wd     string
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	Getenv func(string) string
+	// Environ returns environment variables. os.Environ. It is used to mask secrets
+	Environ  func() []string
 	Reader   Reader
 	GitLab   GitLab
 	Renderer Renderer
@@ -63,28 +76,13 @@ func (ctrl *ExecController) Exec(ctx context.Context, opts *option.ExecOptions)
 			opts.Repo = cfg.Base.Repo
 		}
 	}
-	result, execErr := ctrl.Executor.Run(ctx, &execute.Params{
-		Cmd:   opts.Args[0],
-		Args:  opts.Args[1:],
-		Stdin: ctrl.Stdin,
-	})
-
-	if opts.SkipComment {
-		if execErr != nil {
-			return ecerror.Wrap(execErr, result.ExitCode)
-		}
-		return nil
-	}
-
-	execConfigs, err := ctrl.getExecConfigs(cfg, opts)
+	settings, err := getExecRunSettings(cfg, opts)
 	if err != nil {
-		return fmt.Errorf("get config: %w", err)
+		return err
 	}
-
-	if err := option.ValidateExec(opts); err != nil {
-		return fmt.Errorf("validate command options: %w", err)
+	if err := settings.validate(opts); err != nil {
+		return err
 	}
-
 	if cfg.Vars == nil {
 		cfg.Vars = make(map[string]interface{}, len(opts.Vars))
 	}
@@ -99,20 +97,99 @@ func (ctrl *ExecController) Exec(ctx context.Context, opts *option.ExecOptions)
 	if ctrl.Platform != nil {
 		ci = ctrl.Platform.CI()
 	}
-	joinCommand := strings.Join(opts.Args, " ")
+
+	masker, err := ctrl.newMasker(cfg.Mask, opts)
+	if err != nil {
+		return err
+	}
+
+	// relative paths in the configuration are resolved from the command's working directory
+	dir := resolveDir(ctrl.Wd, settings.Dir)
+	settings.Stdin = ctrl.Stdin
+	if opts.PTY && !isTerminal(ctrl.Stdin) {
+		// under a pseudo-terminal the standard input is copied by gitlab-comment instead of being inherited.
+		// An inherited pipe such as the job script mustn't be consumed, so only a terminal and stdin_file are copied
+		settings.Stdin = nil
+	}
+	if settings.StdinFile != "" {
+		stdinFile := settings.StdinFile
+		if !filepath.IsAbs(stdinFile) {
+			stdinFile = filepath.Join(dir, stdinFile)
+		}
+		f, err := os.Open(stdinFile)
+		if err != nil {
+			return fmt.Errorf("open a file for the standard input: %w", err)
+		}
+		defer f.Close()
+		settings.Stdin = f
+	}
+
+	var live *liveNote
+	if opts.Live && !opts.SkipComment {
+		live = ctrl.startLive(opts, settings, ci, masker)
+		if live != nil {
+			settings.Output = live.tail
+		}
+	}
+	result, execErr := ctrl.runWithRetry(ctx, opts, settings)
+	if live != nil {
+		live.stop()
+	}
+	if result == nil {
+		// the command couldn't be run, so there is nothing to comment
+		return execErr
+	}
+	masker.maskResult(result)
+
+	fs := ctrl.readFindings(dir, settings.SARIF, settings.Checkstyle)
+	files, captureErr := readCaptureFiles(dir, settings.CaptureFiles)
+	for _, file := range files {
+		file.Content = masker.Mask(file.Content)
+	}
+	joinCommand := result.JoinCommand
 	templates := template.GetTemplates(&template.ParamGetTemplates{
 		Templates:      cfg.Templates,
 		CI:             ci,
 		JoinCommand:    joinCommand,
 		CombinedOutput: result.CombinedOutput,
 	})
-	if err := ctrl.post(ctx, execConfigs, &ExecCommentParams{
-		ExitCode:        result.ExitCode,
-		Command:         result.Cmd,
-		JoinCommand:     joinCommand,
-		Stdout:          result.Stdout,
-		Stderr:          result.Stderr,
-		CombinedOutput:  result.CombinedOutput,
+	cmtParams := &ExecCommentParams{
+		ExitCode:       result.ExitCode,
+		Command:        result.Cmd,
+		JoinCommand:    joinCommand,
+		Stdout:         result.Stdout,
+		Stderr:         result.Stderr,
+		CombinedOutput: result.CombinedOutput,
+		TimedOut:       result.TimedOut,
+		Canceled:       result.Canceled,
+		Timeout:        settings.Timeout,
+		Duration:       result.Duration,
+		StartTime:      result.StartTime,
+		EndTime:        result.EndTime,
+		UserTime:       result.UserTime,
+		SystemTime:     result.SystemTime,
+		MaxRSS:         result.MaxRSS,
+		Signal:         result.Signal,
+		Steps:          result.Steps,
+		Dir:            settings.Dir,
+		Attempts:       result.Attempts,
+		MaxAttempts:    result.MaxAttempts,
+
+		RawCombinedOutput: result.RawCombinedOutput,
+		Output:            result.Output,
+		Tests:             ctrl.readJUnitReports(dir, settings.JUnit),
+		Findings:          fs,
+		FindingCounts:     fs.Counts(),
+		Terraform:         ctrl.readTerraformPlan(dir, settings.Terraform, result),
+		Files:             files,
+
+		StdoutTruncated:            result.StdoutTruncated,
+		StderrTruncated:            result.StderrTruncated,
+		CombinedOutputTruncated:    result.CombinedOutputTruncated,
+		StdoutOmittedBytes:         result.StdoutOmittedBytes,
+		StderrOmittedBytes:         result.StderrOmittedBytes,
+		CombinedOutputOmittedBytes: result.CombinedOutputOmittedBytes,
+
 		MRNumber:        opts.MRNumber,
 		Org:             opts.Org,
 		Repo:            opts.Repo,
@@ -121,24 +198,172 @@ func (ctrl *ExecController) Exec(ctx context.Context, opts *option.ExecOptions)
 		Template:        opts.Template,
 		UpdateCondition: opts.UpdateCondition,
 		Vars:            cfg.Vars,
-	}, templates); err != nil {
-		if !opts.Silent {
-			fmt.Fprintf(ctrl.Stderr, "gitlab-comment error: %+v\n", err)
+	}
+	masker.maskParams(cmtParams)
+
+	if opts.SkipComment {
+		if err := ctrl.exitError(settings, opts, cmtParams, execErr); err != nil {
+			return err
 		}
+		return captureErr
+	}
+
+	execConfigs, err := ctrl.getExecConfigs(cfg, opts)
+	if err != nil {
+		return fmt.Errorf("get config: %w", err)
+	}
+
+	if err := option.ValidateExec(opts); err != nil {
+		return fmt.Errorf("validate command options: %w", err)
+	}
+
+	var postErr error
+	if cmtParams.Canceled {
+		// ctx has already been canceled, so the comment is posted within the deadline before gitlab-comment is killed
+		postErr = runWithDeadline(cancelCommentTimeout, func() error {
+			return ctrl.postResult(context.Background(), live, opts, execConfigs, cmtParams, templates)
+		})
+	} else {
+		postErr = ctrl.postResult(ctx, live, opts, execConfigs, cmtParams, templates)
 	}
-	if execErr != nil {
-		return ecerror.Wrap(execErr, result.ExitCode)
+	if postErr != nil && !opts.Silent {
+		fmt.Fprintf(ctrl.Stderr, "gitlab-comment error: %+v\n", postErr)
+	}
+	exitErr := ctrl.exitError(settings, opts, cmtParams, execErr)
+	if exitErr != nil {
+		return exitErr
+	}
+	if captureErr != nil {
+		return captureErr
+	}
+	if postErr != nil && opts.CommentErrorExitCode != 0 {
+		return ecerror.Wrap(fmt.Errorf("post a comment: %w", postErr), opts.CommentErrorExitCode)
 	}
 	return nil
 }
 
+// postResult gets the result of the previous run if needed and posts comments.
+func (ctrl *ExecController) postResult(
+	ctx context.Context, live *liveNote, opts *option.ExecOptions, execConfigs []*config.ExecConfig,
+	cmtParams *ExecCommentParams, templates map[string]string,
+) error {
+	if opts.Template == "" && cmtParams.MRNumber != 0 && hasEmbeddedResults(execConfigs) {
+		previous, err := ctrl.getPreviousResults(cmtParams)
+		if err != nil {
+			logrus.WithError(err).Warn("get results of the previous run")
+		}
+		cmtParams.Previous = previous
+	}
+	return ctrl.post(ctx, live, execConfigs, cmtParams, templates)
+}
+
+// runWithDeadline runs fn and returns an error if fn doesn't finish within the timeout.
+// fn keeps running after the timeout, but it's expected that gitlab-comment exits soon.
+func runWithDeadline(timeout time.Duration, fn func() error) error {
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- fn()
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-errCh:
+		return err
+	case <-timer.C:
+		return fmt.Errorf("posting a comment didn't finish within %s", timeout)
+	}
+}
+
+// exitError returns an error with the exit code of gitlab-comment.
+// The command's exit code is converted by exit_code_map, and fail_on decides whether gitlab-comment fails.
+// If nil is returned, gitlab-comment exits with 0.
+func (ctrl *ExecController) exitError(settings *execRunSettings, opts *option.ExecOptions, cmtParams *ExecCommentParams, execErr error) error {
+	exitCode := cmtParams.ExitCode
+	if code, ok := settings.ExitCodeMap[exitCode]; ok {
+		exitCode = code
+	}
+	if settings.FailOn != "" {
+		f, err := ctrl.Expr.Match(settings.FailOn, cmtParams)
+		if err != nil {
+			return fmt.Errorf("test fail_on: %w", err)
+		}
+		if !f {
+			return nil
+		}
+		if exitCode == 0 {
+			exitCode = 1
+		}
+		if execErr == nil {
+			execErr = errors.New("fail_on is matched: " + settings.FailOn)
+		}
+	}
+	if exitCode == 0 || opts.DontPropagateExitCode {
+		return nil
+	}
+	if execErr == nil {
+		execErr = fmt.Errorf("the exit code %d is mapped to %d by exit_code_map", cmtParams.ExitCode, exitCode)
+	}
+	return ecerror.Wrap(execErr, exitCode)
+}
+
 type ExecCommentParams struct {
 	Stdout         string
 	Stderr         string
 	CombinedOutput string
-	Command        string
-	JoinCommand    string
-	ExitCode       int
+	// RawCombinedOutput is the combined output including ANSI escape sequences such as colors.
+	// Use it with the builtin template diff_combined_output or the template function ANSIToDiff,
+	// which highlight red and green lines because GitLab doesn't render colors in comments
+	RawCombinedOutput string
+	// Output is lines of the standard output and the standard error output with their streams and times.
+	// Use it with the built-in template "timestamped_output"
+	Output      execute.Output
+	Command     string
+	JoinCommand string
+	ExitCode    int
+	// TimedOut is true if the command is terminated because of the timeout
+	TimedOut bool
+	// Canceled is true if the command is terminated because the job is canceled
+	Canceled bool
+	Timeout  time.Duration
+	Duration time.Duration
+	// StartTime and EndTime are times when the command starts and exits
+	StartTime time.Time
+	EndTime   time.Time
+	// UserTime and SystemTime are CPU times of the command.
+	// If steps or batch commands are run, they are the sum of all commands
+	UserTime   time.Duration
+	SystemTime time.Duration
+	// MaxRSS is the peak resident set size of the command in bytes. It is zero on Windows
+	MaxRSS int64
+	// Signal is the name of the signal which terminated the command such as "SIGKILL".
+	// If the command exited normally, Signal is empty
+	Signal string
+	// Dir is the working directory of the command. If it is empty, the current directory is used
+	Dir string
+	// Attempts are results of all attempts including the last one. If the command isn't retried, Attempts has one element
+	Attempts    []*Attempt
+	MaxAttempts int
+	// Steps are results of steps. If steps aren't used, Steps is nil
+	Steps []*StepResult
+	// Tests is the result of JUnit XML reports. If no report is configured, Tests is empty
+	Tests *junit.Tests
+	// Findings are issues in SARIF and Checkstyle reports
+	Findings      findings.Findings
+	FindingCounts *findings.Counts
+	// Terraform is the summary of terraform plan. If terraform isn't enabled, Terraform.Parsed is false
+	Terraform *terraform.Plan
+	// Files are contents of files produced by the command. The key is the name of capture_files
+	Files map[string]*CapturedFile
+	// Previous are results embedded in the comment of the previous run by embedded_result_names.
+	// If the previous comment isn't found, Previous is nil
+	Previous *PreviousResults
+	// StdoutTruncated is true if the middle of the standard output is omitted
+	StdoutTruncated            bool
+	StderrTruncated            bool
+	CombinedOutputTruncated    bool
+	StdoutOmittedBytes         int64
+	StderrOmittedBytes         int64
+	CombinedOutputOmittedBytes int64
 	// MRNumber is the merge request number where the comment is posted
 	MRNumber int
 	// Org is the GitHub Organization or User name
@@ -172,10 +397,13 @@ func (ctrl *ExecController) getExecConfigs(cfg *config.Config, opts *option.Exec
 			}
 			execConfigs = []*config.ExecConfig{
 				{
-					When:            "ExitCode != 0",
+					When:            "ExitCode != 0 || Canceled",
 					UpdateCondition: `Comment.HasMeta && Comment.Meta.TemplateKey == "default"`,
-					Template: `{{template "status" .}} {{template "link" .}}
-{{template "join_command" .}}
+					Template: `{{template "status" .}} {{template "link" .}}{{if .Dir}} (dir: ` + "`{{.Dir}}`" + `){{end}}
+{{if .Canceled}}{{template "canceled" .}}
+{{else if .TimedOut}}{{template "timed_out" .}}
+{{end}}{{if gt (len .Attempts) 1}}{{template "attempts" .}}
+{{end}}{{template "join_command" .}}
 {{template "hidden_combined_output" .}}`,
 				},
 			}
@@ -204,25 +432,80 @@ func (ctrl *ExecController) getExecConfig(
 	return nil, false, nil
 }
 
-// getComment returns Comment.
-// If the second returned value is false, no comment is posted.
-func (ctrl *ExecController) getComment(execConfigs []*config.ExecConfig, cmtParams *ExecCommentParams, templates map[string]string) (*gitlab.Note, bool, error) { //nolint:funlen,cyclop
-	tpl := cmtParams.Template
-	tplForTooLong := ""
-	var embeddedVarNames []string
-	var UpdateCondition string
-	if tpl == "" {
-		execConfig, f, err := ctrl.getExecConfig(execConfigs, cmtParams)
+// getMatchedExecConfigs returns matched ExecConfigs.
+// ExecConfigs are tested in order, and the test stops at the first matched ExecConfig unless it has continue: true.
+func (ctrl *ExecController) getMatchedExecConfigs(
+	execConfigs []*config.ExecConfig, cmtParams *ExecCommentParams,
+) ([]*config.ExecConfig, error) {
+	var matched []*config.ExecConfig
+	rest := execConfigs
+	for len(rest) != 0 {
+		execConfig, f, err := ctrl.getExecConfig(rest, cmtParams)
 		if err != nil {
-			return nil, false, err
+			return nil, err
 		}
 		if !f {
-			return nil, false, nil
+			break
 		}
+		matched = append(matched, execConfig)
+		if !execConfig.Continue {
+			break
+		}
+		for i, c := range rest {
+			if c == execConfig {
+				rest = rest[i+1:]
+				break
+			}
+		}
+	}
+	return matched, nil
+}
+
+// getComments returns comments which are posted.
+// If the template is given by the command line option, ExecConfigs are ignored.
+// The live note is replaced with the first comment.
+func (ctrl *ExecController) getComments(
+	execConfigs []*config.ExecConfig, cmtParams *ExecCommentParams, templates map[string]string, liveNoteID int,
+) ([]*gitlab.Note, error) {
+	if cmtParams.Template != "" {
+		note, err := ctrl.getComment(nil, cmtParams, templates, liveNoteID)
+		if err != nil {
+			return nil, err
+		}
+		return []*gitlab.Note{note}, nil
+	}
+	matched, err := ctrl.getMatchedExecConfigs(execConfigs, cmtParams)
+	if err != nil {
+		return nil, err
+	}
+	var notes []*gitlab.Note
+	var errs []error
+	for _, execConfig := range matched {
 		if execConfig.DontComment {
-			return nil, false, nil
+			continue
+		}
+		note, err := ctrl.getComment(execConfig, cmtParams, templates, liveNoteID)
+		if err != nil {
+			errs = append(errs, err)
+			continue
 		}
+		liveNoteID = 0
+		notes = append(notes, note)
+	}
+	return notes, joinErrors(errs)
+}
+
+// getComment returns Comment.
+// If execConfig is nil, the template given by the command line option is used.
+// If liveNoteID isn't zero, the live note is replaced with the comment and the update condition is ignored.
+func (ctrl *ExecController) getComment(execConfig *config.ExecConfig, cmtParams *ExecCommentParams, templates map[string]string, liveNoteID int) (*gitlab.Note, error) { //nolint:funlen,cyclop
+	tpl := cmtParams.Template
+	tplForTooLong := ""
+	var embeddedVarNames, embeddedResultNames []string
+	var UpdateCondition string
+	if execConfig != nil {
 		tpl = execConfig.Template
+		embeddedResultNames = execConfig.EmbeddedResultNames
 		tplForTooLong = execConfig.TemplateForTooLong
 		embeddedVarNames = execConfig.EmbeddedVarNames
 		UpdateCondition = execConfig.UpdateCondition
@@ -233,11 +516,11 @@ func (ctrl *ExecController) getComment(execConfigs []*config.ExecConfig, cmtPara
 
 	body, err := ctrl.Renderer.Render(tpl, templates, cmtParams)
 	if err != nil {
-		return nil, false, fmt.Errorf("render a comment template: %w", err)
+		return nil, fmt.Errorf("render a comment template: %w", err)
 	}
 	bodyForTooLong, err := ctrl.Renderer.Render(tplForTooLong, templates, cmtParams)
 	if err != nil {
-		return nil, false, fmt.Errorf("render a comment template_for_too_long: %w", err)
+		return nil, fmt.Errorf("render a comment template_for_too_long: %w", err)
 	}
 
 	noteCtrl := NoteController{
@@ -258,13 +541,22 @@ func (ctrl *ExecController) getComment(execConfigs []*config.ExecConfig, cmtPara
 		}
 	}
 
-	embeddedComment, err := noteCtrl.getEmbeddedComment(map[string]interface{}{
+	embeddedData := map[string]interface{}{
 		"SHA1":        cmtParams.SHA1,
 		"TemplateKey": cmtParams.TemplateKey,
 		"Vars":        embeddedMetadata,
-	})
+	}
+	if len(embeddedResultNames) != 0 {
+		results, err := newResults(embeddedResultNames, cmtParams)
+		if err != nil {
+			return nil, err
+		}
+		embeddedData["Results"] = results
+	}
+
+	embeddedComment, err := noteCtrl.getEmbeddedComment(embeddedData)
 	if err != nil {
-		return nil, false, err
+		return nil, err
 	}
 
 	body += embeddedComment
@@ -280,13 +572,15 @@ func (ctrl *ExecController) getComment(execConfigs []*config.ExecConfig, cmtPara
 		Vars:           cmtParams.Vars,
 		TemplateKey:    cmtParams.TemplateKey,
 	}
-	if UpdateCondition != "" && cmtParams.MRNumber != 0 {
+	if liveNoteID != 0 {
+		note.ID = liveNoteID
+	} else if UpdateCondition != "" && cmtParams.MRNumber != 0 {
 		if err := ctrl.setUpdatedCommentID(&note, UpdateCondition); err != nil {
-			return nil, false, fmt.Errorf("set updateCommentID: %w", err)
+			return nil, fmt.Errorf("set updateCommentID: %w", err)
 		}
 	}
 
-	return &note, true, nil
+	return &note, nil
 }
 
 func (ctrl *ExecController) setUpdatedCommentID(note *gitlab.Note, updateCondition string) error {
@@ -349,36 +643,76 @@ func (ctrl *ExecController) setUpdatedCommentID(note *gitlab.Note, updateConditi
 }
 
 func (ctrl *ExecController) post(
-	ctx context.Context, execConfigs []*config.ExecConfig, cmtParams *ExecCommentParams,
+	ctx context.Context, live *liveNote, execConfigs []*config.ExecConfig, cmtParams *ExecCommentParams,
 	templates map[string]string,
 ) error {
-	note, f, err := ctrl.getComment(execConfigs, cmtParams, templates)
-	if err != nil {
-		return err
+	liveNoteID := 0
+	if live != nil {
+		liveNoteID = live.note.ID
 	}
-	if !f {
-		return nil
+	notes, err := ctrl.getComments(execConfigs, cmtParams, templates, liveNoteID)
+	errs := []error{err}
+	if len(notes) == 0 && live != nil {
+		// no comment is posted, so the live note is updated to show the command has finished
+		if err := live.update(false, cmtParams.ExitCode); err != nil {
+			errs = append(errs, fmt.Errorf("update the live note: %w", err))
+		}
 	}
-	logrus.WithFields(logrus.Fields{
-		"org":       note.Org,
-		"repo":      note.Repo,
-		"pr_number": note.MRNumber,
-		"sha":       note.SHA1,
-	}).Debug("comment meta data")
-
 	noteCtrl := NoteController{
 		GitLab: ctrl.GitLab,
 		Expr:   ctrl.Expr,
 		Getenv: ctrl.Getenv,
 	}
-	return noteCtrl.Post(ctx, note, map[string]interface{}{
-		"Command": map[string]interface{}{
-			"ExitCode":       cmtParams.ExitCode,
-			"JoinCommand":    cmtParams.JoinCommand,
-			"Command":        cmtParams.Command,
-			"Stdout":         cmtParams.Stdout,
-			"Stderr":         cmtParams.Stderr,
-			"CombinedOutput": cmtParams.CombinedOutput,
-		},
-	})
+	for _, note := range notes {
+		logrus.WithFields(logrus.Fields{
+			"org":       note.Org,
+			"repo":      note.Repo,
+			"pr_number": note.MRNumber,
+			"sha":       note.SHA1,
+		}).Debug("comment meta data")
+		if err := noteCtrl.Post(ctx, note, map[string]interface{}{
+			"Command": map[string]interface{}{
+				"ExitCode":       cmtParams.ExitCode,
+				"JoinCommand":    cmtParams.JoinCommand,
+				"Command":        cmtParams.Command,
+				"Stdout":         cmtParams.Stdout,
+				"Stderr":         cmtParams.Stderr,
+				"CombinedOutput": cmtParams.CombinedOutput,
+				"TimedOut":       cmtParams.TimedOut,
+				"Duration":       cmtParams.Duration,
+				"Steps":          cmtParams.Steps,
+			},
+		}); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return joinErrors(errs)
+}
+
+// isTerminal returns true if r is a terminal.
+func isTerminal(r io.Reader) bool {
+	f, ok := r.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
+
+// joinErrors joins errors into one error. nil errors are ignored.
+// If there is no error, nil is returned.
+func joinErrors(errs []error) error {
+	var msgs []string
+	var last error
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		last = err
+		msgs = append(msgs, err.Error())
+	}
+	switch len(msgs) {
+	case 0:
+		return nil
+	case 1:
+		return last
+	default:
+		return fmt.Errorf("%d errors occurred: %s", len(msgs), strings.Join(msgs, "; "))
+	}
 }
@@ -1,11 +1,15 @@
 package api
 
 import (
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
+	"github.com/suzuki-shunsuke/go-error-with-exit-code/ecerror"
 	"github.com/yuyaban/gitlab-comment/pkg/config"
 	"github.com/yuyaban/gitlab-comment/pkg/expr"
+	"github.com/yuyaban/gitlab-comment/pkg/option"
 )
 
 func TestExecController_getExecConfig(t *testing.T) { //nolint:funlen
@@ -76,3 +80,446 @@ func TestExecController_getExecConfig(t *testing.T) { //nolint:funlen
 		})
 	}
 }
+
+func TestExecController_getMatchedExecConfigs(t *testing.T) { //nolint:funlen
+	t.Parallel()
+	data := []struct {
+		title       string
+		execConfigs []*config.ExecConfig
+		exp         []string
+	}{
+		{
+			title: "first matched config only",
+			execConfigs: []*config.ExecConfig{
+				{When: "false", Template: "foo"},
+				{When: "true", Template: "bar"},
+				{When: "true", Template: "baz"},
+			},
+			exp: []string{"bar"},
+		},
+		{
+			title: "continue",
+			execConfigs: []*config.ExecConfig{
+				{When: "true", Template: "foo", Continue: true},
+				{When: "false", Template: "bar"},
+				{When: "true", Template: "baz", Continue: true},
+				{When: "true", Template: "qux"},
+				{When: "true", Template: "quux"},
+			},
+			exp: []string{"foo", "baz", "qux"},
+		},
+		{
+			title: "no config matches",
+			execConfigs: []*config.ExecConfig{
+				{When: "false", Template: "foo", Continue: true},
+			},
+		},
+	}
+	ctrl := &ExecController{
+		Expr: &expr.Expr{},
+	}
+	for _, d := range data {
+		d := d
+		t.Run(d.title, func(t *testing.T) {
+			t.Parallel()
+			execConfigs, err := ctrl.getMatchedExecConfigs(d.execConfigs, &ExecCommentParams{})
+			require.Nil(t, err)
+			var tpls []string
+			for _, execConfig := range execConfigs {
+				tpls = append(tpls, execConfig.Template)
+			}
+			require.Equal(t, d.exp, tpls)
+		})
+	}
+}
+
+func Test_getExecRunSettings(t *testing.T) { //nolint:funlen
+	t.Parallel()
+	steps := []*config.ExecStep{
+		{
+			Name: "test",
+			Run:  "go test ./...",
+		},
+	}
+	data := []struct {
+		title string
+		cfg   *config.Config
+		opts  *option.ExecOptions
+		exp   *execRunSettings
+		isErr bool
+	}{
+		{
+			title: "no config",
+			cfg:   &config.Config{},
+			opts: &option.ExecOptions{
+				Options: option.Options{
+					TemplateKey: "default",
+				},
+				Args: []string{"true"},
+			},
+			exp: &execRunSettings{
+				MaxOutputSize: defaultMaxOutputSize,
+			},
+		},
+		{
+			title: "settings are collected from exec configs",
+			cfg: &config.Config{
+				Exec: map[string][]*config.ExecConfig{
+					"default": {
+						{
+							When:    "ExitCode != 0",
+							Timeout: time.Minute,
+						},
+						{
+							When:    "true",
+							Timeout: 10 * time.Minute,
+							Steps:   steps,
+						},
+					},
+				},
+			},
+			opts: &option.ExecOptions{
+				Options: option.Options{
+					TemplateKey: "default",
+				},
+			},
+			exp: &execRunSettings{
+				Timeout:       10 * time.Minute,
+				Steps:         steps,
+				MaxOutputSize: defaultMaxOutputSize,
+			},
+		},
+		{
+			title: "command line options take precedence",
+			cfg: &config.Config{
+				Exec: map[string][]*config.ExecConfig{
+					"default": {
+						{
+							When:    "true",
+							Timeout: 10 * time.Minute,
+							Steps:   steps,
+						},
+					},
+				},
+			},
+			opts: &option.ExecOptions{
+				Options: option.Options{
+					TemplateKey: "default",
+				},
+				Timeout: time.Minute,
+				Shell:   "make lint | tee out",
+			},
+			exp: &execRunSettings{
+				Timeout:       time.Minute,
+				Shell:         "make lint | tee out",
+				MaxOutputSize: defaultMaxOutputSize,
+			},
+		},
+		{
+			title: "dir and env",
+			cfg: &config.Config{
+				Exec: map[string][]*config.ExecConfig{
+					"default": {
+						{
+							When: "ExitCode != 0",
+							Dir:  "modules/a",
+							Env: map[string]string{
+								"FOO": "1",
+								"BAR": "2",
+							},
+						},
+						{
+							When: "true",
+							Dir:  "modules/a",
+							Env: map[string]string{
+								"FOO": "1",
+								"ZOO": "3",
+							},
+						},
+						{
+							When: "false",
+						},
+					},
+				},
+			},
+			opts: &option.ExecOptions{
+				Options: option.Options{
+					TemplateKey: "default",
+				},
+				Args: []string{"make"},
+				Env: map[string]string{
+					"BAR": "cli",
+				},
+			},
+			exp: &execRunSettings{
+				Dir:           "modules/a",
+				Env:           []string{"BAR=cli", "FOO=1", "ZOO=3"},
+				MaxOutputSize: defaultMaxOutputSize,
+			},
+		},
+		{
+			title: "the output parsed by terraform isn't cut",
+			cfg:   &config.Config{},
+			opts: &option.ExecOptions{
+				Options: option.Options{
+					TemplateKey: "default",
+				},
+				Args:      []string{"terraform", "plan"},
+				Terraform: true,
+			},
+			exp: &execRunSettings{
+				Terraform: &config.ExecTerraform{
+					Enabled: true,
+				},
+				MaxOutputSize: -1,
+			},
+		},
+		{
+			title: "max_output_size is kept in terraform mode",
+			cfg: &config.Config{
+				Exec: map[string][]*config.ExecConfig{
+					"default": {
+						{
+							When:          "true",
+							MaxOutputSize: 100,
+							Terraform: &config.ExecTerraform{
+								Enabled: true,
+							},
+						},
+					},
+				},
+			},
+			opts: &option.ExecOptions{
+				Options: option.Options{
+					TemplateKey: "default",
+				},
+				Args: []string{"terraform", "plan"},
+			},
+			exp: &execRunSettings{
+				Terraform: &config.ExecTerraform{
+					Enabled: true,
+				},
+				MaxOutputSize: 100,
+			},
+		},
+		{
+			title: "dir conflicts",
+			cfg: &config.Config{
+				Exec: map[string][]*config.ExecConfig{
+					"default": {
+						{
+							When: "ExitCode != 0",
+							Dir:  "modules/a",
+						},
+						{
+							When: "true",
+							Dir:  "modules/b",
+						},
+					},
+				},
+			},
+			opts: &option.ExecOptions{
+				Options: option.Options{
+					TemplateKey: "default",
+				},
+				Args: []string{"make"},
+			},
+			isErr: true,
+		},
+		{
+			title: "env conflicts",
+			cfg: &config.Config{
+				Exec: map[string][]*config.ExecConfig{
+					"default": {
+						{
+							When: "ExitCode != 0",
+							Env:  map[string]string{"FOO": "1"},
+						},
+						{
+							When: "true",
+							Env:  map[string]string{"FOO": "2"},
+						},
+					},
+				},
+			},
+			opts: &option.ExecOptions{
+				Options: option.Options{
+					TemplateKey: "default",
+				},
+				Args: []string{"make"},
+			},
+			isErr: true,
+		},
+		{
+			title: "the same capture file",
+			cfg: &config.Config{
+				Exec: map[string][]*config.ExecConfig{
+					"default": {
+						{
+							When:         "ExitCode != 0",
+							CaptureFiles: []*config.ExecCaptureFile{{Name: "log", Path: "out.log"}},
+						},
+						{
+							When:         "true",
+							CaptureFiles: []*config.ExecCaptureFile{{Name: "log", Path: "out.log"}},
+						},
+					},
+				},
+			},
+			opts: &option.ExecOptions{
+				Options: option.Options{
+					TemplateKey: "default",
+				},
+				Args: []string{"make"},
+			},
+			exp: &execRunSettings{
+				MaxOutputSize: defaultMaxOutputSize,
+				CaptureFiles:  []*config.ExecCaptureFile{{Name: "log", Path: "out.log"}},
+			},
+		},
+		{
+			title: "capture file conflicts",
+			cfg: &config.Config{
+				Exec: map[string][]*config.ExecConfig{
+					"default": {
+						{
+							When:         "ExitCode != 0",
+							CaptureFiles: []*config.ExecCaptureFile{{Name: "log", Path: "out.log"}},
+						},
+						{
+							When:         "true",
+							CaptureFiles: []*config.ExecCaptureFile{{Name: "log", Path: "err.log"}},
+						},
+					},
+				},
+			},
+			opts: &option.ExecOptions{
+				Options: option.Options{
+					TemplateKey: "default",
+				},
+				Args: []string{"make"},
+			},
+			isErr: true,
+		},
+		{
+			title: "retry conflicts",
+			cfg: &config.Config{
+				Exec: map[string][]*config.ExecConfig{
+					"default": {
+						{
+							When:  "ExitCode != 0",
+							Retry: &config.ExecRetry{Attempts: 2},
+						},
+						{
+							When:  "true",
+							Retry: &config.ExecRetry{Attempts: 3},
+						},
+					},
+				},
+			},
+			opts: &option.ExecOptions{
+				Options: option.Options{
+					TemplateKey: "default",
+				},
+				Args: []string{"make"},
+			},
+			isErr: true,
+		},
+	}
+	for _, d := range data {
+		d := d
+		t.Run(d.title, func(t *testing.T) {
+			t.Parallel()
+			settings, err := getExecRunSettings(d.cfg, d.opts)
+			if d.isErr {
+				require.NotNil(t, err)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, d.exp, settings)
+		})
+	}
+}
+
+func TestExecController_exitError(t *testing.T) { //nolint:funlen
+	t.Parallel()
+	data := []struct {
+		title    string
+		settings *execRunSettings
+		opts     *option.ExecOptions
+		exitCode int
+		exp      int
+	}{
+		{
+			title:    "success",
+			settings: &execRunSettings{},
+			opts:     &option.ExecOptions{},
+			exitCode: 0,
+			exp:      0,
+		},
+		{
+			title:    "failure",
+			settings: &execRunSettings{},
+			opts:     &option.ExecOptions{},
+			exitCode: 1,
+			exp:      1,
+		},
+		{
+			title: "exit_code_map",
+			settings: &execRunSettings{
+				ExitCodeMap: map[int]int{2: 0},
+			},
+			opts:     &option.ExecOptions{},
+			exitCode: 2,
+			exp:      0,
+		},
+		{
+			title: "fail_on isn't matched",
+			settings: &execRunSettings{
+				FailOn: "ExitCode == 1",
+			},
+			opts:     &option.ExecOptions{},
+			exitCode: 2,
+			exp:      0,
+		},
+		{
+			title: "fail_on is matched",
+			settings: &execRunSettings{
+				FailOn: "ExitCode == 0",
+			},
+			opts:     &option.ExecOptions{},
+			exitCode: 0,
+			exp:      1,
+		},
+		{
+			title:    "don't propagate exit code",
+			settings: &execRunSettings{},
+			opts: &option.ExecOptions{
+				DontPropagateExitCode: true,
+			},
+			exitCode: 1,
+			exp:      0,
+		},
+	}
+	ctrl := &ExecController{
+		Expr: &expr.Expr{},
+	}
+	for _, d := range data {
+		d := d
+		t.Run(d.title, func(t *testing.T) {
+			t.Parallel()
+			var execErr error
+			if d.exitCode != 0 {
+				execErr = errors.New("exit status")
+			}
+			err := ctrl.exitError(d.settings, d.opts, &ExecCommentParams{
+				ExitCode: d.exitCode,
+			}, execErr)
+			if d.exp == 0 {
+				require.Nil(t, err)
+				return
+			}
+			require.Equal(t, d.exp, ecerror.GetExitCode(err))
+		})
+	}
+}
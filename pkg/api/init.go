@@ -1,8 +1,15 @@
 package api
 
 import (
+	"bufio"
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
 	"strings"
+
+	"github.com/yuyaban/gitlab-comment/pkg/option"
 )
 
 const cfgTemplate = `---
@@ -65,19 +72,191 @@ const cfgTemplate = `---
 #         ` + "```" + `
 `
 
+// minimalPreset is the base of all presets.
+const minimalPreset = `---
+# skip_no_token: true
+post:
+  default:
+    template: |
+      {{.Vars.message}}
+exec:
+  default:
+    - when: ExitCode != 0
+      update: 'Comment.HasMeta && Comment.Meta.TemplateKey == "default"'
+      template: |
+        {{template "status" .}} {{template "link" .}}
+        {{template "join_command" .}}
+        {{template "hidden_combined_output" .}}
+`
+
+// execPresets are exec configurations for common commands.
+// They are appended to the exec section of minimalPreset.
+var execPresets = map[string]string{ //nolint:gochecknoglobals
+	"terraform": `  # gitlab-comment exec -k plan -- terraform plan
+  plan:
+    - when: ExitCode == 0
+      terraform: true
+      update: 'Comment.HasMeta && Comment.Meta.TemplateKey == "plan"'
+      template: |
+        ## {{if .Terraform.HasDestroy}}:warning:{{else}}:white_check_mark:{{end}} terraform plan succeeded {{template "link" .}}
+        {{template "terraform_plan" .}}
+        {{template "join_command" .}}
+        {{template "hidden_combined_output" .}}
+    - when: ExitCode != 0
+      update: 'Comment.HasMeta && Comment.Meta.TemplateKey == "plan"'
+      template: |
+        ## :x: terraform plan failed {{template "link" .}}
+        {{template "join_command" .}}
+        {{template "hidden_combined_output" .}}
+  # gitlab-comment exec -k apply -- terraform apply -auto-approve
+  apply:
+    - when: ExitCode != 0
+      template: |
+        ## :x: terraform apply failed {{template "link" .}}
+        {{template "join_command" .}}
+        {{template "hidden_combined_output" .}}
+`,
+	"lint": `  # gitlab-comment exec -k lint -- golangci-lint run
+  lint:
+    - when: ExitCode != 0
+      update: 'Comment.HasMeta && Comment.Meta.TemplateKey == "lint"'
+      template: |
+        ## :x: lint failed {{template "link" .}}
+        {{template "join_command" .}}
+        {{template "hidden_combined_output" .}}
+    - when: ExitCode == 0
+      update: 'Comment.HasMeta && Comment.Meta.TemplateKey == "lint"'
+      template: |
+        ## :white_check_mark: lint passed {{template "link" .}}
+`,
+	"test": `  # gitlab-comment exec -k test -- go test ./...
+  test:
+    - when: ExitCode != 0
+      update: 'Comment.HasMeta && Comment.Meta.TemplateKey == "test"'
+      template: |
+        ## :x: test failed {{template "link" .}}
+        {{template "join_command" .}}
+        {{template "hidden_combined_output" .}}
+    - when: ExitCode == 0
+      update: 'Comment.HasMeta && Comment.Meta.TemplateKey == "test"'
+      template: |
+        ## :white_check_mark: test passed {{template "link" .}}
+`,
+}
+
+const presetMinimal = "minimal"
+
+func presetNames() []string {
+	names := make([]string, 0, len(execPresets)+1)
+	for name := range execPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return append([]string{presetMinimal}, names...)
+}
+
+// buildPreset builds a configuration from presets.
+// The exec configurations of presets are appended to minimalPreset.
+func buildPreset(presets []string) (string, error) {
+	cfg := minimalPreset
+	for _, preset := range presets {
+		if preset == presetMinimal {
+			continue
+		}
+		a, ok := execPresets[preset]
+		if !ok {
+			return "", fmt.Errorf("unknown preset %s. preset must be one of %s", preset, strings.Join(presetNames(), ", "))
+		}
+		cfg += a
+	}
+	return cfg, nil
+}
+
+// parsePresets parses comma separated preset names.
+// Duplicated names are removed, because they would generate duplicated keys.
+func parsePresets(s string) []string {
+	var presets []string
+	found := map[string]struct{}{}
+	for _, preset := range strings.Split(s, ",") {
+		preset = strings.TrimSpace(preset)
+		if preset == "" {
+			continue
+		}
+		if _, ok := found[preset]; ok {
+			continue
+		}
+		found[preset] = struct{}{}
+		presets = append(presets, preset)
+	}
+	return presets
+}
+
 type Fsys interface {
 	Exist(string) bool
+	Read(path string) ([]byte, error)
 	Write(path string, content []byte) error
 }
 
 type InitController struct {
-	Fsys Fsys
+	Fsys   Fsys
+	Stdin  io.Reader
+	Stderr io.Writer
+	// IsInteractive returns true if the standard input is a terminal.
+	// If it is true and no preset is given, presets are asked interactively
+	IsInteractive func() bool
 }
 
-func (ctrl InitController) Run(ctx context.Context) error {
-	dst := "gitlab-comment.yaml"
-	if ctrl.Fsys.Exist(dst) {
+func (ctrl InitController) Run(ctx context.Context, opts *option.InitOptions) error {
+	dst := opts.Output
+	if dst == "" {
+		dst = "gitlab-comment.yaml"
+	}
+	if !opts.Force && ctrl.Fsys.Exist(dst) {
+		fmt.Fprintln(ctrl.Stderr, dst+" already exists. Use --force to overwrite it")
 		return nil
 	}
-	return ctrl.Fsys.Write(dst, []byte(strings.Trim(cfgTemplate, "\n"))) //nolint:wrapcheck
+	content, err := ctrl.getContent(opts)
+	if err != nil {
+		return err
+	}
+	if err := ctrl.Fsys.Write(dst, []byte(content)); err != nil {
+		return fmt.Errorf("write a configuration file %s: %w", dst, err)
+	}
+	fmt.Fprintln(ctrl.Stderr, dst+" is created")
+	return nil
+}
+
+func (ctrl InitController) getContent(opts *option.InitOptions) (string, error) {
+	if opts.Preset != "" {
+		return buildPreset(parsePresets(opts.Preset))
+	}
+	if ctrl.IsInteractive == nil || !ctrl.IsInteractive() {
+		return strings.Trim(cfgTemplate, "\n"), nil
+	}
+	presets, err := ctrl.askPresets()
+	if err != nil {
+		return "", err
+	}
+	return buildPreset(presets)
+}
+
+// askPresets asks which commands are wrapped with gitlab-comment exec.
+func (ctrl InitController) askPresets() ([]string, error) {
+	scanner := bufio.NewScanner(ctrl.Stdin)
+	for {
+		fmt.Fprintf(ctrl.Stderr, "Which commands do you wrap with 'gitlab-comment exec'? Choose from %s (comma separated, empty for minimal): ",
+			strings.Join(presetNames()[1:], ", "))
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				return nil, fmt.Errorf("read the standard input: %w", err)
+			}
+			return nil, errors.New("no answer is given")
+		}
+		presets := parsePresets(scanner.Text())
+		if _, err := buildPreset(presets); err != nil {
+			fmt.Fprintln(ctrl.Stderr, err)
+			continue
+		}
+		return presets, nil
+	}
 }
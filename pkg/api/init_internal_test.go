@@ -0,0 +1,117 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yuyaban/gitlab-comment/pkg/config"
+	"github.com/yuyaban/gitlab-comment/pkg/option"
+	"gopkg.in/yaml.v2"
+)
+
+func TestInitController_Run(t *testing.T) { //nolint:funlen
+	t.Parallel()
+	data := []struct {
+		title       string
+		files       map[string][]byte
+		opts        *option.InitOptions
+		stdin       string
+		interactive bool
+		path        string
+		execKeys    []string
+		unchanged   bool
+		isErr       bool
+	}{
+		{
+			title:    "minimal preset",
+			files:    map[string][]byte{},
+			opts:     &option.InitOptions{Preset: "minimal"},
+			path:     "gitlab-comment.yaml",
+			execKeys: []string{"default"},
+		},
+		{
+			title:    "duplicated presets",
+			files:    map[string][]byte{},
+			opts:     &option.InitOptions{Preset: "lint, lint,test"},
+			path:     "gitlab-comment.yaml",
+			execKeys: []string{"default", "lint", "test"},
+		},
+		{
+			title:    "terraform preset to --output",
+			files:    map[string][]byte{},
+			opts:     &option.InitOptions{Preset: "terraform", Output: ".gitlab-comment.yml"},
+			path:     ".gitlab-comment.yml",
+			execKeys: []string{"apply", "default", "plan"},
+		},
+		{
+			title: "unknown preset",
+			files: map[string][]byte{},
+			opts:  &option.InitOptions{Preset: "foo"},
+			isErr: true,
+		},
+		{
+			title: "file exists",
+			files: map[string][]byte{
+				"gitlab-comment.yaml": []byte("old"),
+			},
+			opts:      &option.InitOptions{Preset: "lint"},
+			path:      "gitlab-comment.yaml",
+			unchanged: true,
+		},
+		{
+			title: "file is overwritten with --force",
+			files: map[string][]byte{
+				"gitlab-comment.yaml": []byte("old"),
+			},
+			opts:     &option.InitOptions{Preset: "lint", Force: true},
+			path:     "gitlab-comment.yaml",
+			execKeys: []string{"default", "lint"},
+		},
+		{
+			title:       "presets are asked interactively",
+			files:       map[string][]byte{},
+			opts:        &option.InitOptions{},
+			stdin:       "foo\ntest,lint\n",
+			interactive: true,
+			path:        "gitlab-comment.yaml",
+			execKeys:    []string{"default", "lint", "test"},
+		},
+	}
+	for _, d := range data {
+		d := d
+		t.Run(d.title, func(t *testing.T) {
+			t.Parallel()
+			fsys := &memFsys{files: d.files}
+			ctrl := InitController{
+				Fsys:   fsys,
+				Stdin:  strings.NewReader(d.stdin),
+				Stderr: &bytes.Buffer{},
+				IsInteractive: func() bool {
+					return d.interactive
+				},
+			}
+			err := ctrl.Run(context.Background(), d.opts)
+			if d.isErr {
+				require.NotNil(t, err)
+				return
+			}
+			require.Nil(t, err)
+			if d.unchanged {
+				require.Equal(t, "old", string(fsys.files[d.path]))
+				return
+			}
+			cfg := &config.Config{}
+			require.Nil(t, yaml.UnmarshalStrict(fsys.files[d.path], cfg))
+			keys := make([]string, 0, len(cfg.Exec))
+			for key := range cfg.Exec {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			require.Equal(t, d.execKeys, keys)
+		})
+	}
+}
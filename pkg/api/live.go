@@ -0,0 +1,237 @@
+package api
+
+import (
+	"bytes"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/yuyaban/gitlab-comment/pkg/gitlab"
+	"github.com/yuyaban/gitlab-comment/pkg/option"
+	"github.com/yuyaban/gitlab-comment/pkg/template"
+)
+
+const (
+	// liveTailSize is the maximum size of the output shown in the live note
+	liveTailSize = 4096
+	// minLiveInterval is the minimum interval of updating the live note to avoid GitLab API's rate limit
+	minLiveInterval = 5 * time.Second
+	// maxLiveInterval is the maximum interval of updating the live note when the update fails
+	maxLiveInterval = 5 * time.Minute
+)
+
+// liveTemplate is the template of the live note. It is defined as a builtin template so that users can override it
+const liveTemplate = `{{template "live" .}}`
+
+// liveTail is an io.Writer which keeps the tail of the written data.
+// It is safe for concurrent use.
+type liveTail struct {
+	mutex sync.Mutex
+	buf   []byte
+	limit int
+}
+
+func (tail *liveTail) Write(p []byte) (int, error) {
+	tail.mutex.Lock()
+	defer tail.mutex.Unlock()
+	tail.buf = append(tail.buf, p...)
+	// trim the buffer lazily to avoid copying the buffer every time.
+	// The byte before the tail is kept to know whether the tail starts at a line boundary
+	if len(tail.buf) > 2*tail.limit {
+		tail.buf = append(tail.buf[:0], tail.buf[len(tail.buf)-tail.limit-1:]...)
+	}
+	return len(p), nil
+}
+
+// String returns the tail of the data. If the data is trimmed, the first partial line is removed.
+func (tail *liveTail) String() string {
+	tail.mutex.Lock()
+	defer tail.mutex.Unlock()
+	if len(tail.buf) <= tail.limit {
+		return string(tail.buf)
+	}
+	b := tail.buf[len(tail.buf)-tail.limit:]
+	if tail.buf[len(tail.buf)-tail.limit-1] == '\n' {
+		return string(b)
+	}
+	if i := bytes.IndexByte(b, '\n'); i >= 0 && i < len(b)-1 {
+		b = b[i+1:]
+	}
+	return string(b)
+}
+
+type liveParams struct {
+	JoinCommand string
+	ExitCode    int
+	Running     bool
+	Elapsed     time.Duration
+	Tail        string
+	Vars        map[string]interface{}
+}
+
+// liveNote is a note which shows the progress of the running command.
+// The note is created when the command starts and updated periodically.
+// When the command exits, the note is always replaced with the first comment regardless of the update condition,
+// because the note belongs to this run.
+type liveNote struct {
+	ctrl            *ExecController
+	note            *gitlab.Note
+	templates       map[string]string
+	embeddedComment string
+	joinCommand     string
+	vars            map[string]interface{}
+	tail            *liveTail
+	masker          *masker
+	interval        time.Duration
+	startTime       time.Time
+	lastBody        string
+	done            chan struct{}
+	wg              sync.WaitGroup
+}
+
+func (live *liveNote) render(running bool, exitCode int) (string, error) {
+	body, err := live.ctrl.Renderer.Render(liveTemplate, live.templates, &liveParams{
+		JoinCommand: live.joinCommand,
+		ExitCode:    exitCode,
+		Running:     running,
+		Elapsed:     time.Since(live.startTime),
+		Tail:        live.masker.Mask(live.tail.String()),
+		Vars:        live.vars,
+	})
+	if err != nil {
+		return "", err //nolint:wrapcheck
+	}
+	return body + live.embeddedComment, nil
+}
+
+// update renders the note and updates it if the body is changed.
+func (live *liveNote) update(running bool, exitCode int) error {
+	body, err := live.render(running, exitCode)
+	if err != nil {
+		return err
+	}
+	if body == live.lastBody {
+		return nil
+	}
+	live.note.Body = body
+	live.note.BodyForTooLong = body
+	if err := live.ctrl.GitLab.CreateComment(live.note); err != nil {
+		return err //nolint:wrapcheck
+	}
+	live.lastBody = body
+	return nil
+}
+
+// start creates the note and starts updating it periodically.
+func (live *liveNote) start() error {
+	live.startTime = time.Now()
+	if err := live.update(true, 0); err != nil {
+		return err
+	}
+	live.done = make(chan struct{})
+	live.wg.Add(1)
+	go live.loop()
+	return nil
+}
+
+func (live *liveNote) loop() {
+	defer live.wg.Done()
+	interval := live.interval
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	for {
+		select {
+		case <-live.done:
+			return
+		case <-timer.C:
+		}
+		err := live.update(true, 0)
+		interval = live.nextInterval(interval, err)
+		if err != nil {
+			logrus.WithError(err).WithField("interval", interval).Warn("update the live note")
+		}
+		timer.Reset(interval)
+	}
+}
+
+// nextInterval returns the interval until the next update.
+// If the update fails, the interval is doubled up to maxLiveInterval to avoid hitting GitLab API's rate limit repeatedly.
+func (live *liveNote) nextInterval(interval time.Duration, err error) time.Duration {
+	if err == nil {
+		return live.interval
+	}
+	interval *= 2
+	if interval > maxLiveInterval {
+		return maxLiveInterval
+	}
+	return interval
+}
+
+// liveInterval returns the interval of updating the live note. It is at least minLiveInterval.
+func liveInterval(interval time.Duration) time.Duration {
+	if interval < minLiveInterval {
+		return minLiveInterval
+	}
+	return interval
+}
+
+// stop stops updating the note. The note is left as is.
+func (live *liveNote) stop() {
+	close(live.done)
+	live.wg.Wait()
+}
+
+// startLive creates the live note. If the note can't be created, the live mode is disabled and nil is returned.
+func (ctrl *ExecController) startLive(opts *option.ExecOptions, settings *execRunSettings, ci string, masker *masker) *liveNote {
+	if opts.MRNumber == 0 {
+		logrus.Warn("the live mode is disabled because the merge request isn't found")
+		return nil
+	}
+	joinCommand := masker.Mask(settings.joinCommand(opts))
+	noteCtrl := NoteController{
+		GitLab:   ctrl.GitLab,
+		Expr:     ctrl.Expr,
+		Getenv:   ctrl.Getenv,
+		Platform: ctrl.Platform,
+	}
+	embeddedComment, err := noteCtrl.getEmbeddedComment(map[string]interface{}{
+		"SHA1":        opts.SHA1,
+		"TemplateKey": opts.TemplateKey,
+		"Vars": map[string]interface{}{
+			"target": ctrl.Config.Vars["target"],
+		},
+	})
+	if err != nil {
+		logrus.WithError(err).Warn("the live mode is disabled because metadata can't be embedded")
+		return nil
+	}
+	live := &liveNote{
+		ctrl: ctrl,
+		note: &gitlab.Note{
+			MRNumber:    opts.MRNumber,
+			Org:         opts.Org,
+			Repo:        opts.Repo,
+			SHA1:        opts.SHA1,
+			Vars:        ctrl.Config.Vars,
+			TemplateKey: opts.TemplateKey,
+		},
+		templates: template.GetTemplates(&template.ParamGetTemplates{
+			Templates:   ctrl.Config.Templates,
+			CI:          ci,
+			JoinCommand: joinCommand,
+		}),
+		embeddedComment: embeddedComment,
+		joinCommand:     joinCommand,
+		vars:            ctrl.Config.Vars,
+		tail: &liveTail{
+			limit: liveTailSize,
+		},
+		masker:   masker,
+		interval: liveInterval(opts.LiveInterval),
+	}
+	if err := live.start(); err != nil {
+		logrus.WithError(err).Warn("the live mode is disabled because the live note can't be created")
+		return nil
+	}
+	return live
+}
@@ -0,0 +1,237 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yuyaban/gitlab-comment/pkg/gitlab"
+	"github.com/yuyaban/gitlab-comment/pkg/template"
+)
+
+// fakeGitLab records created notes. The first failures calls of CreateComment fail.
+type fakeGitLab struct {
+	mutex    sync.Mutex
+	failures int
+	notes    []gitlab.Note
+	lastID   int
+}
+
+func (fake *fakeGitLab) CreateComment(note *gitlab.Note) error {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	if fake.failures > 0 {
+		fake.failures--
+		return errors.New("rate limit exceeded")
+	}
+	if note.ID == 0 {
+		fake.lastID++
+		note.ID = fake.lastID
+	}
+	fake.notes = append(fake.notes, *note)
+	return nil
+}
+
+func (fake *fakeGitLab) created() []gitlab.Note {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	return append([]gitlab.Note(nil), fake.notes...)
+}
+
+func (fake *fakeGitLab) ListNote(mr *gitlab.MergeRequest) ([]*gitlab.Note, error) {
+	return nil, nil
+}
+
+func (fake *fakeGitLab) HideComment(nodeID int) error {
+	return nil
+}
+
+func (fake *fakeGitLab) MRNumberWithSHA(owner, repo, sha string) (int, error) {
+	return 0, nil
+}
+
+func (fake *fakeGitLab) ListMaskedVariableNames(owner, repo string) ([]string, error) {
+	return nil, nil
+}
+
+func TestLiveTail(t *testing.T) {
+	t.Parallel()
+	data := []struct {
+		title  string
+		limit  int
+		inputs []string
+		exp    string
+	}{
+		{
+			title:  "not trimmed",
+			limit:  10,
+			inputs: []string{"foo\n", "bar\n"},
+			exp:    "foo\nbar\n",
+		},
+		{
+			title:  "the first partial line is removed",
+			limit:  10,
+			inputs: []string{"foo\n", "bar\n", "baz\n", "qux\n"},
+			exp:    "baz\nqux\n",
+		},
+		{
+			title:  "a line longer than the limit",
+			limit:  4,
+			inputs: []string{"foo\n", "abcdefgh"},
+			exp:    "efgh",
+		},
+		{
+			title:  "buffer is trimmed lazily",
+			limit:  4,
+			inputs: []string{"1\n2\n3\n4\n5\n6\n", "7\n8\n"},
+			exp:    "7\n8\n",
+		},
+	}
+	for _, d := range data {
+		d := d
+		t.Run(d.title, func(t *testing.T) {
+			t.Parallel()
+			tail := &liveTail{limit: d.limit}
+			for _, input := range d.inputs {
+				n, err := tail.Write([]byte(input))
+				require.Nil(t, err)
+				require.Equal(t, len(input), n)
+			}
+			require.Equal(t, d.exp, tail.String())
+			require.LessOrEqual(t, len(tail.buf), 2*d.limit)
+		})
+	}
+}
+
+func TestLiveInterval(t *testing.T) {
+	t.Parallel()
+	data := []struct {
+		title    string
+		interval time.Duration
+		exp      time.Duration
+	}{
+		{
+			title: "zero",
+			exp:   minLiveInterval,
+		},
+		{
+			title:    "less than the minimum",
+			interval: time.Second,
+			exp:      minLiveInterval,
+		},
+		{
+			title:    "valid",
+			interval: time.Minute,
+			exp:      time.Minute,
+		},
+	}
+	for _, d := range data {
+		d := d
+		t.Run(d.title, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, d.exp, liveInterval(d.interval))
+		})
+	}
+}
+
+func TestLiveNote_nextInterval(t *testing.T) {
+	t.Parallel()
+	data := []struct {
+		title    string
+		interval time.Duration
+		err      error
+		exp      time.Duration
+	}{
+		{
+			title:    "reset after success",
+			interval: 2 * time.Minute,
+			exp:      30 * time.Second,
+		},
+		{
+			title:    "doubled after failure",
+			interval: 30 * time.Second,
+			err:      errors.New("rate limit exceeded"),
+			exp:      time.Minute,
+		},
+		{
+			title:    "up to the maximum",
+			interval: 4 * time.Minute,
+			err:      errors.New("rate limit exceeded"),
+			exp:      maxLiveInterval,
+		},
+	}
+	for _, d := range data {
+		d := d
+		t.Run(d.title, func(t *testing.T) {
+			t.Parallel()
+			live := &liveNote{interval: 30 * time.Second}
+			require.Equal(t, d.exp, live.nextInterval(d.interval, d.err))
+		})
+	}
+}
+
+func newTestLiveNote(gl GitLab, interval time.Duration) *liveNote {
+	return &liveNote{
+		ctrl: &ExecController{
+			GitLab:   gl,
+			Renderer: &template.Renderer{},
+		},
+		note: &gitlab.Note{
+			MRNumber:    1,
+			TemplateKey: "default",
+		},
+		templates: template.GetTemplates(&template.ParamGetTemplates{
+			JoinCommand: "make",
+		}),
+		joinCommand: "make",
+		tail: &liveTail{
+			limit: liveTailSize,
+		},
+		interval: interval,
+	}
+}
+
+func TestLiveNote_loop(t *testing.T) {
+	t.Parallel()
+	gl := &fakeGitLab{}
+	live := newTestLiveNote(gl, time.Millisecond)
+	require.Nil(t, live.start())
+	// the loop keeps updating the note after failures
+	gl.mutex.Lock()
+	gl.failures = 3
+	gl.mutex.Unlock()
+	live.tail.Write([]byte("building\n")) //nolint:errcheck
+	require.Eventually(t, func() bool {
+		notes := gl.created()
+		return len(notes) == 2 && strings.Contains(notes[1].Body, "building")
+	}, 5*time.Second, time.Millisecond)
+	live.stop()
+	// the note is created once and then updated
+	for _, note := range gl.created() {
+		require.Equal(t, 1, note.ID)
+	}
+}
+
+func TestExecController_post_live(t *testing.T) {
+	t.Parallel()
+	gl := &fakeGitLab{}
+	live := newTestLiveNote(gl, time.Hour)
+	require.Nil(t, live.start())
+	live.stop()
+	cmtParams := &ExecCommentParams{
+		MRNumber:    1,
+		TemplateKey: "default",
+		Template:    "failed",
+		// the update condition is ignored because the live note is replaced
+		UpdateCondition: "true",
+	}
+	require.Nil(t, live.ctrl.post(context.Background(), live, nil, cmtParams, nil))
+	notes := gl.created()
+	require.Len(t, notes, 2)
+	require.Equal(t, 1, notes[1].ID)
+	require.True(t, strings.HasPrefix(notes[1].Body, "failed"))
+}
@@ -0,0 +1,202 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/yuyaban/gitlab-comment/pkg/config"
+	"github.com/yuyaban/gitlab-comment/pkg/execute"
+	"github.com/yuyaban/gitlab-comment/pkg/option"
+)
+
+const (
+	maskedValue = "[MASKED]"
+	// minMaskedValueLength is the minimum length of masked values.
+	// Shorter values such as "1" and "true" are too common to be masked
+	minMaskedValueLength = 4
+)
+
+// builtinMaskedEnv are glob patterns of environment variables whose values are always masked
+var builtinMaskedEnv = []string{ //nolint:gochecknoglobals
+	"CI_*_TOKEN",
+	"CI_JOB_JWT*",
+	"CI_REGISTRY_PASSWORD",
+	"CI_DEPENDENCY_PROXY_PASSWORD",
+	"GITLAB_TOKEN",
+	"GITLAB_ACCESS_TOKEN",
+}
+
+// masker redacts secrets in texts.
+type masker struct {
+	// values are sorted by length in descending order so that a longer secret is masked first
+	values   []string
+	patterns []*regexp.Regexp
+}
+
+func (m *masker) Mask(s string) string {
+	if m == nil || s == "" {
+		return s
+	}
+	for _, v := range m.values {
+		s = strings.ReplaceAll(s, v, maskedValue)
+	}
+	for _, p := range m.patterns {
+		s = p.ReplaceAllString(s, maskedValue)
+	}
+	return m.maskAroundOmission(s)
+}
+
+// omittedLinesPattern matches the line which replaces the middle of an output cut by max_output_size.
+// "…" is put before and after the line if a line is cut in the middle
+var omittedLinesPattern = regexp.MustCompile(`(…\n)?… \d+ lines omitted …\n(…)?`) //nolint:gochecknoglobals
+
+// maskAroundOmission masks a part of a secret at the place where a line is cut by max_output_size.
+// A secret across the omitted part isn't masked by its value because only its prefix and suffix are kept.
+// Regular expressions of mask.patterns aren't applied to the parts.
+func (m *masker) maskAroundOmission(s string) string {
+	locs := omittedLinesPattern.FindAllStringSubmatchIndex(s, -1)
+	if len(locs) == 0 || len(m.values) == 0 {
+		return s
+	}
+	var b strings.Builder
+	start := 0
+	tailCut := false
+	for _, loc := range locs {
+		segment := s[start:loc[0]]
+		if tailCut {
+			segment = m.maskPartialPrefix(segment)
+		}
+		if loc[2] >= 0 {
+			segment = m.maskPartialSuffix(segment)
+		}
+		b.WriteString(segment)
+		b.WriteString(s[loc[0]:loc[1]])
+		start = loc[1]
+		tailCut = loc[4] >= 0
+	}
+	segment := s[start:]
+	if tailCut {
+		segment = m.maskPartialPrefix(segment)
+	}
+	b.WriteString(segment)
+	return b.String()
+}
+
+// maskPartialSuffix masks the longest end of s which is the beginning of a secret.
+func (m *masker) maskPartialSuffix(s string) string {
+	n := 0
+	for _, v := range m.values {
+		for k := len(v) - 1; k > n; k-- {
+			if strings.HasSuffix(s, v[:k]) {
+				n = k
+				break
+			}
+		}
+	}
+	if n == 0 {
+		return s
+	}
+	return s[:len(s)-n] + maskedValue
+}
+
+// maskPartialPrefix masks the longest beginning of s which is the end of a secret.
+func (m *masker) maskPartialPrefix(s string) string {
+	n := 0
+	for _, v := range m.values {
+		for k := len(v) - 1; k > n; k-- {
+			if strings.HasPrefix(s, v[len(v)-k:]) {
+				n = k
+				break
+			}
+		}
+	}
+	if n == 0 {
+		return s
+	}
+	return maskedValue + s[n:]
+}
+
+// newMasker collects secrets from environment variables and compiles regular expressions.
+func (ctrl *ExecController) newMasker(cfg *config.Mask, opts *option.ExecOptions) (*masker, error) {
+	names := append([]string{}, builtinMaskedEnv...)
+	m := &masker{}
+	if cfg != nil { //nolint:nestif
+		names = append(names, cfg.Env...)
+		for _, pattern := range cfg.Patterns {
+			p, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("compile a regular expression of mask.patterns: %w", err)
+			}
+			m.patterns = append(m.patterns, p)
+		}
+		// the API is called only if a comment is posted because the masked output is used only in comments
+		if cfg.GitLabMaskedVariables && !opts.SkipComment {
+			maskedNames, err := ctrl.GitLab.ListMaskedVariableNames(opts.Org, opts.Repo)
+			if err != nil {
+				logrus.WithError(err).Warn("get masked CI/CD variables. Values of masked CI/CD variables aren't masked. mask.gitlab_masked_variables requires a token of a project maintainer")
+			}
+			names = append(names, maskedNames...)
+		}
+	}
+	if ctrl.Environ == nil {
+		return m, nil
+	}
+	for _, kv := range ctrl.Environ() {
+		a := strings.SplitN(kv, "=", 2)                      //nolint:gomnd
+		if len(a) != 2 || len(a[1]) < minMaskedValueLength { //nolint:gomnd
+			continue
+		}
+		if execute.MatchName(a[0], names) {
+			m.values = append(m.values, a[1])
+		}
+	}
+	sort.Slice(m.values, func(i, j int) bool {
+		return len(m.values[i]) > len(m.values[j])
+	})
+	return m, nil
+}
+
+// maskResult masks secrets in the result of the command and reports.
+func (m *masker) maskResult(result *runResult) {
+	result.Cmd = m.Mask(result.Cmd)
+	result.JoinCommand = m.Mask(result.JoinCommand)
+	result.Stdout = m.Mask(result.Stdout)
+	result.Stderr = m.Mask(result.Stderr)
+	result.CombinedOutput = m.Mask(result.CombinedOutput)
+	result.RawCombinedOutput = m.Mask(result.RawCombinedOutput)
+	m.maskOutput(result.Output)
+	for _, attempt := range result.Attempts {
+		attempt.Stdout = m.Mask(attempt.Stdout)
+		attempt.Stderr = m.Mask(attempt.Stderr)
+		attempt.CombinedOutput = m.Mask(attempt.CombinedOutput)
+	}
+	for _, step := range result.Steps {
+		step.JoinCommand = m.Mask(step.JoinCommand)
+		step.Stdout = m.Mask(step.Stdout)
+		step.Stderr = m.Mask(step.Stderr)
+		step.CombinedOutput = m.Mask(step.CombinedOutput)
+		step.RawCombinedOutput = m.Mask(step.RawCombinedOutput)
+		m.maskOutput(step.Output)
+	}
+}
+
+func (m *masker) maskOutput(output execute.Output) {
+	for _, line := range output {
+		line.Text = m.Mask(line.Text)
+	}
+}
+
+func (m *masker) maskParams(params *ExecCommentParams) {
+	if params.Tests != nil {
+		for _, testCase := range params.Tests.Failures {
+			testCase.Message = m.Mask(testCase.Message)
+			testCase.Output = m.Mask(testCase.Output)
+		}
+	}
+	for _, finding := range params.Findings {
+		finding.Message = m.Mask(finding.Message)
+	}
+}
@@ -0,0 +1,206 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yuyaban/gitlab-comment/pkg/config"
+	"github.com/yuyaban/gitlab-comment/pkg/findings"
+	"github.com/yuyaban/gitlab-comment/pkg/junit"
+	"github.com/yuyaban/gitlab-comment/pkg/option"
+)
+
+func TestExecController_newMasker(t *testing.T) { //nolint:funlen
+	t.Parallel()
+	data := []struct {
+		title string
+		cfg   *config.Mask
+		input string
+		exp   string
+		isErr bool
+	}{
+		{
+			title: "builtin",
+			input: "token: job-token, deploy: deploy-secret, user: alice",
+			exp:   "token: [MASKED], deploy: [MASKED], user: alice",
+		},
+		{
+			title: "env and patterns",
+			cfg: &config.Mask{
+				Env:      []string{"AWS_*"},
+				Patterns: []string{`ghp_[A-Za-z0-9]+`, `alice`},
+			},
+			input: "aws: aws-secret, github: ghp_abc123, user: alice",
+			exp:   "aws: [MASKED], github: [MASKED], user: [MASKED]",
+		},
+		{
+			title: "invalid pattern",
+			cfg: &config.Mask{
+				Patterns: []string{`(`},
+			},
+			isErr: true,
+		},
+	}
+	environ := []string{
+		"CI_JOB_TOKEN=job-token",
+		"CI_DEPLOY_TOKEN=deploy-secret",
+		"AWS_SECRET_ACCESS_KEY=aws-secret",
+		"AWS_EMPTY=",
+		"GITLAB_USER_LOGIN=alice",
+	}
+	for _, d := range data {
+		d := d
+		t.Run(d.title, func(t *testing.T) {
+			t.Parallel()
+			ctrl := &ExecController{
+				Environ: func() []string {
+					return environ
+				},
+			}
+			m, err := ctrl.newMasker(d.cfg, &option.ExecOptions{})
+			if d.isErr {
+				require.NotNil(t, err)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, d.exp, m.Mask(d.input))
+		})
+	}
+}
+
+// maskedVariablesGitLab returns names of masked CI/CD variables and records whether they are got.
+type maskedVariablesGitLab struct {
+	fakeGitLab
+	names  []string
+	called bool
+}
+
+func (gl *maskedVariablesGitLab) ListMaskedVariableNames(owner, repo string) ([]string, error) {
+	gl.called = true
+	return gl.names, nil
+}
+
+func TestExecController_newMasker_gitLabMaskedVariables(t *testing.T) {
+	t.Parallel()
+	data := []struct {
+		title       string
+		skipComment bool
+		exp         string
+	}{
+		{
+			title: "masked variables are got",
+			exp:   "deploy: [MASKED]",
+		},
+		{
+			title:       "no comment is posted",
+			skipComment: true,
+			exp:         "deploy: deploy-key",
+		},
+	}
+	for _, d := range data {
+		d := d
+		t.Run(d.title, func(t *testing.T) {
+			t.Parallel()
+			gl := &maskedVariablesGitLab{names: []string{"DEPLOY_KEY"}}
+			ctrl := &ExecController{
+				GitLab: gl,
+				Environ: func() []string {
+					return []string{"DEPLOY_KEY=deploy-key"}
+				},
+			}
+			m, err := ctrl.newMasker(&config.Mask{
+				GitLabMaskedVariables: true,
+			}, &option.ExecOptions{
+				SkipComment: d.skipComment,
+			})
+			require.Nil(t, err)
+			require.Equal(t, !d.skipComment, gl.called)
+			require.Equal(t, d.exp, m.Mask("deploy: deploy-key"))
+		})
+	}
+}
+
+func TestMasker_Mask_omission(t *testing.T) {
+	t.Parallel()
+	data := []struct {
+		title string
+		input string
+		exp   string
+	}{
+		{
+			title: "lines are aligned",
+			input: "token: sec\n… 3 lines omitted …\nret-token\n",
+			exp:   "token: sec\n… 3 lines omitted …\nret-token\n",
+		},
+		{
+			title: "secret across the omitted part",
+			input: "token: secr…\n… 0 lines omitted …\n…et-token is used\n",
+			exp:   "token: [MASKED]…\n… 0 lines omitted …\n…[MASKED] is used\n",
+		},
+		{
+			title: "multiple omissions",
+			input: "a…\n… 0 lines omitted …\n…ken\nsecret…\n… 1 lines omitted …\n…n\n",
+			exp:   "a…\n… 0 lines omitted …\n…[MASKED]\n[MASKED]…\n… 1 lines omitted …\n…[MASKED]\n",
+		},
+		{
+			title: "not a secret",
+			input: "foo…\n… 0 lines omitted …\n…bar\n",
+			exp:   "foo…\n… 0 lines omitted …\n…bar\n",
+		},
+	}
+	for _, d := range data {
+		d := d
+		t.Run(d.title, func(t *testing.T) {
+			t.Parallel()
+			m := &masker{values: []string{"secret-token"}}
+			require.Equal(t, d.exp, m.Mask(d.input))
+		})
+	}
+}
+
+func TestMasker_maskParams(t *testing.T) {
+	t.Parallel()
+	data := []struct {
+		title  string
+		params *ExecCommentParams
+		exp    *ExecCommentParams
+	}{
+		{
+			title:  "no report",
+			params: &ExecCommentParams{},
+			exp:    &ExecCommentParams{},
+		},
+		{
+			title: "tests and findings",
+			params: &ExecCommentParams{
+				Tests: &junit.Tests{
+					Failures: []*junit.TestCase{
+						{Message: "token: secret-token", Output: "secret-token"},
+					},
+				},
+				Findings: findings.Findings{
+					{Message: "secret-token is hard-coded"},
+				},
+			},
+			exp: &ExecCommentParams{
+				Tests: &junit.Tests{
+					Failures: []*junit.TestCase{
+						{Message: "token: [MASKED]", Output: "[MASKED]"},
+					},
+				},
+				Findings: findings.Findings{
+					{Message: "[MASKED] is hard-coded"},
+				},
+			},
+		},
+	}
+	for _, d := range data {
+		d := d
+		t.Run(d.title, func(t *testing.T) {
+			t.Parallel()
+			m := &masker{values: []string{"secret-token"}}
+			m.maskParams(d.params)
+			require.Equal(t, d.exp, d.params)
+		})
+	}
+}
@@ -14,6 +14,7 @@ type GitLab interface {
 	ListNote(mr *gitlab.MergeRequest) ([]*gitlab.Note, error)
 	HideComment(nodeID int) error
 	MRNumberWithSHA(owner, repo, sha string) (int, error)
+	ListMaskedVariableNames(owner, repo string) ([]string, error)
 }
 
 type NoteController struct {
@@ -0,0 +1,271 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/yuyaban/gitlab-comment/pkg/config"
+	"github.com/yuyaban/gitlab-comment/pkg/findings"
+	"github.com/yuyaban/gitlab-comment/pkg/gitlab"
+	"github.com/yuyaban/gitlab-comment/pkg/junit"
+)
+
+// Results are results of the command which are embedded in the comment's metadata,
+// so that the next run can compare its results with them.
+// Only results specified by embedded_result_names are set.
+type Results struct {
+	ExitCode   int               `json:",omitempty"`
+	Duration   time.Duration     `json:",omitempty"`
+	UserTime   time.Duration     `json:",omitempty"`
+	SystemTime time.Duration     `json:",omitempty"`
+	MaxRSS     int64             `json:",omitempty"`
+	Tests      *TestResults      `json:",omitempty"`
+	Findings   *FindingResults   `json:",omitempty"`
+	Terraform  *TerraformResults `json:",omitempty"`
+}
+
+type TestResults struct {
+	Total   int
+	Passed  int
+	Failed  int
+	Errors  int
+	Skipped int
+	// Failures are IDs of failed tests
+	Failures []string `json:",omitempty"`
+}
+
+type FindingResults struct {
+	Error   int
+	Warning int
+	Info    int
+	// IDs are IDs of findings
+	IDs []string `json:",omitempty"`
+}
+
+type TerraformResults struct {
+	Add     int
+	Change  int
+	Destroy int
+	Replace int
+}
+
+// PreviousResults are results embedded in the comment of the previous run.
+type PreviousResults struct {
+	*Results
+	// SHA1 is the commit SHA1 of the previous run
+	SHA1 string
+	// NoteID is the ID of the previous comment
+	NoteID int
+}
+
+// testID returns an identifier of the test case to compare tests between runs.
+func testID(testCase *junit.TestCase) string {
+	if testCase.ClassName == "" {
+		return testCase.Name
+	}
+	return testCase.ClassName + "." + testCase.Name
+}
+
+func failedTestIDs(tests *junit.Tests) []string {
+	if tests == nil {
+		return nil
+	}
+	ids := make([]string, len(tests.Failures))
+	for i, testCase := range tests.Failures {
+		ids[i] = testID(testCase)
+	}
+	return ids
+}
+
+func findingIDs(fs findings.Findings) []string {
+	ids := make([]string, len(fs))
+	for i, finding := range fs {
+		ids[i] = finding.ID()
+	}
+	return ids
+}
+
+// newResults returns results which are embedded in the comment.
+func newResults(names []string, cmtParams *ExecCommentParams) (*Results, error) { //nolint:cyclop
+	results := &Results{}
+	for _, name := range names {
+		switch name {
+		case "ExitCode":
+			results.ExitCode = cmtParams.ExitCode
+		case "Duration":
+			results.Duration = cmtParams.Duration
+		case "UserTime":
+			results.UserTime = cmtParams.UserTime
+		case "SystemTime":
+			results.SystemTime = cmtParams.SystemTime
+		case "MaxRSS":
+			results.MaxRSS = cmtParams.MaxRSS
+		case "Tests":
+			if tests := cmtParams.Tests; tests != nil {
+				results.Tests = &TestResults{
+					Total:    tests.Total,
+					Passed:   tests.Passed,
+					Failed:   tests.Failed,
+					Errors:   tests.Errors,
+					Skipped:  tests.Skipped,
+					Failures: failedTestIDs(tests),
+				}
+			}
+		case "Findings":
+			results.Findings = &FindingResults{
+				IDs: findingIDs(cmtParams.Findings),
+			}
+			if counts := cmtParams.FindingCounts; counts != nil {
+				results.Findings.Error = counts.Error
+				results.Findings.Warning = counts.Warning
+				results.Findings.Info = counts.Info
+			}
+		case "Terraform":
+			if plan := cmtParams.Terraform; plan != nil && plan.Parsed {
+				results.Terraform = &TerraformResults{
+					Add:     len(plan.Add),
+					Change:  len(plan.Change),
+					Destroy: len(plan.Destroy),
+					Replace: len(plan.Replace),
+				}
+			}
+		default:
+			return nil, fmt.Errorf("embedded_result_names has an unknown result name: %s", name)
+		}
+	}
+	return results, nil
+}
+
+// hasEmbeddedResults returns true if any ExecConfig embeds results.
+func hasEmbeddedResults(execConfigs []*config.ExecConfig) bool {
+	for _, execConfig := range execConfigs {
+		if len(execConfig.EmbeddedResultNames) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// getPreviousResults returns results embedded in the latest comment of the same template key and target.
+// If no comment has results, nil is returned.
+func (ctrl *ExecController) getPreviousResults(cmtParams *ExecCommentParams) (*PreviousResults, error) {
+	notes, err := ctrl.GitLab.ListNote(&gitlab.MergeRequest{
+		Org:      cmtParams.Org,
+		Repo:     cmtParams.Repo,
+		MRNumber: cmtParams.MRNumber,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list merge request comments: %w", err)
+	}
+	var previous *PreviousResults
+	for _, note := range notes {
+		p := extractPreviousResults(note, cmtParams.TemplateKey, ctrl.Config.Vars["target"])
+		if p != nil && (previous == nil || p.NoteID > previous.NoteID) {
+			previous = p
+		}
+	}
+	return previous, nil
+}
+
+// extractPreviousResults extracts results from the comment's metadata.
+// If the comment isn't of the template key and the target or the comment has no result, nil is returned.
+func extractPreviousResults(note *gitlab.Note, templateKey string, target interface{}) *PreviousResults {
+	meta := struct {
+		SHA1        string
+		TemplateKey string
+		Vars        map[string]interface{}
+		Results     *Results
+	}{}
+	metadata := map[string]interface{}{}
+	if !extractMetaFromComment(note.Body, &metadata) {
+		return nil
+	}
+	// convert the metadata to the struct through JSON to restore types such as time.Duration
+	b, err := json.Marshal(metadata)
+	if err != nil {
+		return nil
+	}
+	if err := json.Unmarshal(b, &meta); err != nil {
+		logrus.WithError(err).WithField("note_id", note.ID).Debug("parse results in the comment's metadata")
+		return nil
+	}
+	if meta.Results == nil || meta.TemplateKey != templateKey || fmt.Sprint(meta.Vars["target"]) != fmt.Sprint(target) {
+		return nil
+	}
+	return &PreviousResults{
+		Results: meta.Results,
+		SHA1:    meta.SHA1,
+		NoteID:  note.ID,
+	}
+}
+
+// difference returns elements of a which aren't included in b.
+func difference(a, b []string) []string {
+	m := make(map[string]struct{}, len(b))
+	for _, s := range b {
+		m[s] = struct{}{}
+	}
+	var ret []string
+	for _, s := range a {
+		if _, ok := m[s]; !ok {
+			ret = append(ret, s)
+		}
+	}
+	return ret
+}
+
+// NewFailedTests returns IDs of tests which fail in this run but didn't fail in the previous run.
+// If the previous run has no test result, nil is returned.
+func (params *ExecCommentParams) NewFailedTests() []string {
+	if params.Previous == nil || params.Previous.Tests == nil {
+		return nil
+	}
+	return difference(failedTestIDs(params.Tests), params.Previous.Tests.Failures)
+}
+
+// FixedTests returns IDs of tests which failed in the previous run but don't fail in this run.
+func (params *ExecCommentParams) FixedTests() []string {
+	if params.Previous == nil || params.Previous.Tests == nil {
+		return nil
+	}
+	return difference(params.Previous.Tests.Failures, failedTestIDs(params.Tests))
+}
+
+// NewFindings returns findings which weren't found in the previous run.
+// If the previous run has no finding result, nil is returned.
+func (params *ExecCommentParams) NewFindings() findings.Findings {
+	if params.Previous == nil || params.Previous.Findings == nil {
+		return nil
+	}
+	ids := difference(findingIDs(params.Findings), params.Previous.Findings.IDs)
+	m := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		m[id] = struct{}{}
+	}
+	var ret findings.Findings
+	for _, finding := range params.Findings {
+		if _, ok := m[finding.ID()]; ok {
+			ret = append(ret, finding)
+		}
+	}
+	return ret
+}
+
+// FixedFindingCount returns the number of findings which were found in the previous run but aren't found in this run.
+func (params *ExecCommentParams) FixedFindingCount() int {
+	if params.Previous == nil || params.Previous.Findings == nil {
+		return 0
+	}
+	return len(difference(params.Previous.Findings.IDs, findingIDs(params.Findings)))
+}
+
+// DurationChange returns the percentage change of Duration from the previous run.
+// If the previous run has no duration, 0 is returned.
+func (params *ExecCommentParams) DurationChange() float64 {
+	if params.Previous == nil || params.Previous.Duration == 0 {
+		return 0
+	}
+	return float64(params.Duration-params.Previous.Duration) / float64(params.Previous.Duration) * 100 //nolint:gomnd
+}
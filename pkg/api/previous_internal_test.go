@@ -0,0 +1,85 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yuyaban/gitlab-comment/pkg/findings"
+	"github.com/yuyaban/gitlab-comment/pkg/gitlab"
+	"github.com/yuyaban/gitlab-comment/pkg/junit"
+	"github.com/yuyaban/gitlab-comment/pkg/template"
+)
+
+func TestExecCommentParams_Previous(t *testing.T) { //nolint:funlen
+	t.Parallel()
+	prev := &ExecCommentParams{
+		Duration: 2 * time.Minute,
+		Tests: &junit.Tests{
+			Total:  3,
+			Failed: 2,
+			Failures: []*junit.TestCase{
+				{ClassName: "pkg", Name: "TestA"},
+				{ClassName: "pkg", Name: "TestB"},
+			},
+		},
+		Findings: findings.Findings{
+			{File: "a.go", Line: 1, Rule: "r1", Message: "foo"},
+			{File: "a.go", Line: 2, Rule: "r2", Message: "bar"},
+		},
+	}
+	results, err := newResults([]string{"Duration", "Tests", "Findings"}, prev)
+	require.Nil(t, err)
+	noteCtrl := &NoteController{}
+	body, err := noteCtrl.getEmbeddedComment(map[string]interface{}{
+		"SHA1":        "abc123",
+		"TemplateKey": "test",
+		"Vars": map[string]interface{}{
+			"target": "foo",
+		},
+		"Results": results,
+	})
+	require.Nil(t, err)
+	note := &gitlab.Note{ID: 10, Body: "hello" + body}
+
+	require.Nil(t, extractPreviousResults(note, "default", "foo"))
+	require.Nil(t, extractPreviousResults(note, "test", "bar"))
+	previous := extractPreviousResults(note, "test", "foo")
+	require.NotNil(t, previous)
+	require.Equal(t, "abc123", previous.SHA1)
+	require.Equal(t, 10, previous.NoteID)
+	require.Equal(t, 2*time.Minute, previous.Duration)
+
+	cur := &ExecCommentParams{
+		Duration: 3 * time.Minute,
+		Tests: &junit.Tests{
+			Failures: []*junit.TestCase{
+				{ClassName: "pkg", Name: "TestB"},
+				{ClassName: "pkg", Name: "TestC"},
+			},
+		},
+		Findings: findings.Findings{
+			// the finding isn't new even if the line is changed
+			{File: "a.go", Line: 3, Rule: "r1", Message: "foo"},
+			{File: "b.go", Line: 1, Rule: "r1", Message: "foo"},
+		},
+		Previous: previous,
+	}
+	require.Equal(t, []string{"pkg.TestC"}, cur.NewFailedTests())
+	require.Equal(t, []string{"pkg.TestA"}, cur.FixedTests())
+	require.Equal(t, findings.Findings{cur.Findings[1]}, cur.NewFindings())
+	require.Equal(t, 1, cur.FixedFindingCount())
+	require.Equal(t, 50.0, cur.DurationChange())
+
+	renderer := &template.Renderer{}
+	comment, err := renderer.Render(`{{template "previous_summary" .}}`, template.GetTemplates(&template.ParamGetTemplates{}), cur)
+	require.Nil(t, err)
+	require.Equal(t, `Compared with abc123:
+* tests: 1 new failure(s), 1 fixed
+* findings: 1 new, 1 fixed
+* duration: 3m0s (+50%)
+`, comment)
+
+	_, err = newResults([]string{"Unknown"}, cur)
+	require.NotNil(t, err)
+}
@@ -0,0 +1,81 @@
+//go:build linux
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yuyaban/gitlab-comment/pkg/config"
+	"github.com/yuyaban/gitlab-comment/pkg/execute"
+	"github.com/yuyaban/gitlab-comment/pkg/expr"
+	"github.com/yuyaban/gitlab-comment/pkg/option"
+)
+
+func TestExecController_Exec_ptyStdin(t *testing.T) {
+	t.Parallel()
+	data := []struct {
+		title     string
+		stdinFile string
+		exp       string
+	}{
+		{
+			title: "the inherited pipe isn't forwarded",
+		},
+		{
+			title:     "stdin_file is forwarded",
+			stdinFile: "hello\n",
+			exp:       "hello",
+		},
+	}
+	for _, d := range data {
+		d := d
+		t.Run(d.title, func(t *testing.T) {
+			t.Parallel()
+			pr, pw, err := os.Pipe()
+			require.Nil(t, err)
+			defer pr.Close()
+			defer pw.Close()
+			opts := &option.ExecOptions{
+				SkipComment: true,
+				Args:        []string{"cat"},
+				PTY:         true,
+			}
+			if d.stdinFile != "" {
+				opts.StdinFile = filepath.Join(t.TempDir(), "stdin.txt")
+				require.Nil(t, os.WriteFile(opts.StdinFile, []byte(d.stdinFile), 0o600))
+			}
+			stdout := &bytes.Buffer{}
+			ctrl := &ExecController{
+				Stdin:  pr,
+				Stdout: io.Discard,
+				Stderr: io.Discard,
+				Executor: &execute.Executor{
+					Stdout: stdout,
+					Stderr: io.Discard,
+				},
+				Expr:   &expr.Expr{},
+				Config: &config.Config{},
+			}
+			// the pipe is never closed, so cat would block if the pipe were forwarded
+			startTime := time.Now()
+			require.Nil(t, ctrl.Exec(context.Background(), opts))
+			require.Less(t, time.Since(startTime), 5*time.Second)
+			require.Contains(t, stdout.String(), d.exp)
+			// data written after the command exits isn't consumed by gitlab-comment
+			_, err = pw.Write([]byte("after\n"))
+			require.Nil(t, err)
+			require.Nil(t, pr.SetReadDeadline(time.Now().Add(5*time.Second)))
+			b := make([]byte, 6)
+			_, err = io.ReadFull(pr, b)
+			require.Nil(t, err)
+			require.Equal(t, "after\n", string(b))
+		})
+	}
+}
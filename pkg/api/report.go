@@ -0,0 +1,127 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+	"github.com/yuyaban/gitlab-comment/pkg/config"
+	"github.com/yuyaban/gitlab-comment/pkg/findings"
+	"github.com/yuyaban/gitlab-comment/pkg/junit"
+	"github.com/yuyaban/gitlab-comment/pkg/terraform"
+)
+
+// globReports returns paths of reports which match glob patterns.
+// Relative patterns are resolved from dir, which is the working directory of the command.
+func globReports(dir string, patterns []string) []string {
+	var paths []string
+	for _, pattern := range patterns {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(dir, pattern)
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			logrus.WithError(err).WithField("pattern", pattern).Warn("invalid glob pattern of reports")
+			continue
+		}
+		if len(matches) == 0 {
+			logrus.WithField("pattern", pattern).Warn("no report matches the glob pattern")
+		}
+		paths = appendUnique(paths, matches...)
+	}
+	return paths
+}
+
+// readJUnitReports parses JUnit XML reports.
+// Reports which can't be parsed are skipped with warnings, because the comment should be posted anyway.
+func (ctrl *ExecController) readJUnitReports(dir string, patterns []string) *junit.Tests {
+	tests := &junit.Tests{}
+	for _, p := range globReports(dir, patterns) {
+		if err := readJUnitReport(tests, p); err != nil {
+			logrus.WithError(err).WithField("path", p).Warn("read a JUnit XML report")
+		}
+	}
+	return tests
+}
+
+func readJUnitReport(tests *junit.Tests, p string) error {
+	f, err := os.Open(p)
+	if err != nil {
+		return fmt.Errorf("open a JUnit XML report: %w", err)
+	}
+	defer f.Close()
+	return tests.Parse(f) //nolint:wrapcheck
+}
+
+// readFindings parses SARIF and Checkstyle reports.
+// Reports which can't be parsed are skipped with warnings.
+func (ctrl *ExecController) readFindings(dir string, sarifPatterns, checkstylePatterns []string) findings.Findings {
+	ret := findings.Findings{}
+	for _, report := range []struct {
+		patterns []string
+		parse    func(io.Reader, string) (findings.Findings, error)
+	}{
+		{sarifPatterns, findings.ParseSARIF},
+		{checkstylePatterns, findings.ParseCheckstyle},
+	} {
+		for _, p := range globReports(dir, report.patterns) {
+			fs, err := readFindingReport(p, ctrl.Wd, report.parse)
+			if err != nil {
+				logrus.WithError(err).WithField("path", p).Warn("read a linter's report")
+				continue
+			}
+			ret = append(ret, fs...)
+		}
+	}
+	return ret
+}
+
+func readFindingReport(p, wd string, parse func(io.Reader, string) (findings.Findings, error)) (findings.Findings, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, fmt.Errorf("open a report: %w", err)
+	}
+	defer f.Close()
+	return parse(f, wd)
+}
+
+// readTerraformPlan parses the result of terraform plan.
+// If the path to the output of terraform show -json isn't set, the output of the command is parsed.
+// The output can be either the output of terraform plan or terraform show -json.
+// If the middle of the output is omitted, the plan isn't parsed because resources in the omitted part would be missed.
+func (ctrl *ExecController) readTerraformPlan(dir string, tf *config.ExecTerraform, result *runResult) *terraform.Plan {
+	if tf == nil {
+		return &terraform.Plan{}
+	}
+	if tf.PlanJSON != "" {
+		p := tf.PlanJSON
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(dir, p)
+		}
+		b, err := os.ReadFile(p)
+		if err != nil {
+			logrus.WithError(err).WithField("path", p).Warn("read the output of terraform show -json")
+			return &terraform.Plan{}
+		}
+		plan, err := terraform.ParseJSON(b)
+		if err != nil {
+			logrus.WithError(err).WithField("path", p).Warn("parse the output of terraform show -json")
+			return &terraform.Plan{}
+		}
+		return plan
+	}
+	if result.StdoutTruncated || result.CombinedOutputTruncated {
+		logrus.Warn("the result of terraform plan isn't parsed because the output exceeds max_output_size")
+		return &terraform.Plan{Truncated: true}
+	}
+	if terraform.IsJSON(result.Stdout) {
+		plan, err := terraform.ParseJSON([]byte(result.Stdout))
+		if err == nil {
+			return plan
+		}
+		logrus.WithError(err).Warn("parse the output of terraform show -json")
+	}
+	return terraform.ParseText(result.CombinedOutput)
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yuyaban/gitlab-comment/pkg/config"
+	"github.com/yuyaban/gitlab-comment/pkg/execute"
+	"github.com/yuyaban/gitlab-comment/pkg/terraform"
+)
+
+func TestExecController_readTerraformPlan(t *testing.T) { //nolint:funlen
+	t.Parallel()
+	planOutput := `Terraform will perform the following actions:
+
+  # null_resource.foo will be destroyed
+  - resource "null_resource" "foo" {}
+
+Plan: 0 to add, 0 to change, 1 to destroy.
+`
+	data := []struct {
+		title  string
+		tf     *config.ExecTerraform
+		result *execute.Result
+		exp    *terraform.Plan
+	}{
+		{
+			title:  "disabled",
+			result: &execute.Result{},
+			exp:    &terraform.Plan{},
+		},
+		{
+			title: "text",
+			tf:    &config.ExecTerraform{Enabled: true},
+			result: &execute.Result{
+				Stdout:         planOutput,
+				CombinedOutput: planOutput,
+			},
+			exp: &terraform.Plan{
+				Parsed:     true,
+				Destroy:    []string{"null_resource.foo"},
+				HasChanges: true,
+				HasDestroy: true,
+			},
+		},
+		{
+			title: "json",
+			tf:    &config.ExecTerraform{Enabled: true},
+			result: &execute.Result{
+				Stdout:         `{"resource_changes":[{"address":"null_resource.foo","change":{"actions":["create"]}}]}`,
+				CombinedOutput: `{"resource_changes":[{"address":"null_resource.foo","change":{"actions":["create"]}}]}`,
+			},
+			exp: &terraform.Plan{
+				Parsed:     true,
+				Add:        []string{"null_resource.foo"},
+				HasChanges: true,
+			},
+		},
+		{
+			// the destroyed resource may be in the omitted part
+			title: "truncated",
+			tf:    &config.ExecTerraform{Enabled: true},
+			result: &execute.Result{
+				Stdout:                  planOutput,
+				CombinedOutput:          planOutput,
+				StdoutTruncated:         true,
+				CombinedOutputTruncated: true,
+			},
+			exp: &terraform.Plan{
+				Truncated: true,
+			},
+		},
+	}
+	for _, d := range data {
+		d := d
+		t.Run(d.title, func(t *testing.T) {
+			t.Parallel()
+			ctrl := &ExecController{}
+			plan := ctrl.readTerraformPlan("", d.tf, &runResult{Result: d.result})
+			require.Equal(t, d.exp, plan)
+		})
+	}
+}
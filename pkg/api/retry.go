@@ -0,0 +1,121 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/yuyaban/gitlab-comment/pkg/option"
+)
+
+// Attempt is the result of an attempt to run the command.
+type Attempt struct {
+	// Number starts from 1
+	Number         int
+	ExitCode       int
+	Stdout         string
+	Stderr         string
+	CombinedOutput string
+	Duration       time.Duration
+	TimedOut       bool
+	// Signal is the name of the signal which terminated the command
+	Signal string
+}
+
+// runWithRetry runs the command and reruns it while it fails and the retry condition is matched.
+// The result of the last attempt is returned, and all attempts are set to runResult.Attempts.
+func (ctrl *ExecController) runWithRetry(ctx context.Context, opts *option.ExecOptions, settings *execRunSettings) (*runResult, error) {
+	maxAttempts := 1
+	if settings.Retry != nil && settings.Retry.Attempts > 1 {
+		maxAttempts = settings.Retry.Attempts
+	}
+	var attempts []*Attempt
+	replayer := newStdinReplayer(settings.Stdin)
+	for i := 1; ; i++ {
+		result, err := ctrl.run(ctx, opts, settings)
+		if result == nil {
+			return nil, err
+		}
+		attempt := &Attempt{
+			Number:         i,
+			ExitCode:       result.ExitCode,
+			Stdout:         result.Stdout,
+			Stderr:         result.Stderr,
+			CombinedOutput: result.CombinedOutput,
+			Duration:       result.Duration,
+			TimedOut:       result.TimedOut,
+			Signal:         result.Signal,
+		}
+		attempts = append(attempts, attempt)
+		result.Attempts = attempts
+		result.MaxAttempts = maxAttempts
+		if err == nil || i >= maxAttempts || ctx.Err() != nil {
+			return result, err
+		}
+		if settings.Retry.When != "" {
+			f, matchErr := ctrl.Expr.Match(settings.Retry.When, attempt)
+			if matchErr != nil {
+				logrus.WithError(matchErr).Warn("test the retry condition")
+				return result, err
+			}
+			if !f {
+				return result, err
+			}
+		}
+		logrus.WithFields(logrus.Fields{
+			"attempt":   i,
+			"exit_code": result.ExitCode,
+			"delay":     settings.Retry.Delay,
+		}).Warn("the command failed. retry")
+		if settings.Retry.Delay > 0 {
+			timer := time.NewTimer(settings.Retry.Delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return result, err
+			case <-timer.C:
+			}
+		}
+		if rewindErr := replayer.rewind(); rewindErr != nil {
+			logrus.WithError(rewindErr).Warn("the command isn't retried")
+			return result, err
+		}
+	}
+}
+
+// stdinReplayer gives every attempt the same standard input if possible.
+// A seekable input such as a regular file or stdin_file is read again from the same offset.
+// Other inputs such as pipes and terminals are passed as is, so later attempts read only what earlier attempts left.
+// They aren't buffered because reading them would block until the writer closes them
+// and consume the input which gitlab-comment's parent process would read after gitlab-comment exits.
+type stdinReplayer struct {
+	seeker io.Seeker
+	offset int64
+}
+
+func newStdinReplayer(stdin io.Reader) *stdinReplayer {
+	replayer := &stdinReplayer{}
+	if isTerminal(stdin) {
+		return replayer
+	}
+	if seeker, ok := stdin.(io.Seeker); ok {
+		if offset, err := seeker.Seek(0, io.SeekCurrent); err == nil {
+			replayer.seeker = seeker
+			replayer.offset = offset
+		}
+	}
+	return replayer
+}
+
+// rewind makes the standard input read from the beginning again if it's seekable.
+func (replayer *stdinReplayer) rewind() error {
+	if replayer.seeker == nil {
+		return nil
+	}
+	if _, err := replayer.seeker.Seek(replayer.offset, io.SeekStart); err != nil {
+		return fmt.Errorf("rewind the standard input for the retry: %w", err)
+	}
+	return nil
+}
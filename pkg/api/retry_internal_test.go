@@ -0,0 +1,169 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yuyaban/gitlab-comment/pkg/config"
+	"github.com/yuyaban/gitlab-comment/pkg/execute"
+	"github.com/yuyaban/gitlab-comment/pkg/expr"
+	"github.com/yuyaban/gitlab-comment/pkg/option"
+)
+
+// sequenceExecutor returns exit codes in order and records the standard input of each run.
+type sequenceExecutor struct {
+	exitCodes []int
+	stdins    []string
+}
+
+func (executor *sequenceExecutor) Run(ctx context.Context, params *execute.Params) (*execute.Result, error) {
+	exitCode := executor.exitCodes[len(executor.stdins)]
+	stdin := ""
+	if params.Stdin != nil {
+		b, err := io.ReadAll(params.Stdin)
+		if err != nil {
+			return nil, err
+		}
+		stdin = string(b)
+	}
+	executor.stdins = append(executor.stdins, stdin)
+	result := &execute.Result{
+		ExitCode: exitCode,
+	}
+	if exitCode != 0 {
+		return result, errors.New("exit status")
+	}
+	return result, nil
+}
+
+func TestExecController_runWithRetry(t *testing.T) { //nolint:funlen
+	t.Parallel()
+	data := []struct {
+		title     string
+		exitCodes []int
+		retry     *config.ExecRetry
+		canceled  bool
+		exp       []int
+		minTime   time.Duration
+		isErr     bool
+	}{
+		{
+			title:     "no retry",
+			exitCodes: []int{1},
+			exp:       []int{1},
+			isErr:     true,
+		},
+		{
+			title:     "succeeded after retry",
+			exitCodes: []int{1, 2, 0},
+			retry: &config.ExecRetry{
+				Attempts: 3,
+			},
+			exp: []int{1, 2, 0},
+		},
+		{
+			title:     "exhausted",
+			exitCodes: []int{1, 1, 1},
+			retry: &config.ExecRetry{
+				Attempts: 2,
+			},
+			exp:   []int{1, 1},
+			isErr: true,
+		},
+		{
+			title:     "when isn't matched",
+			exitCodes: []int{1, 2, 0},
+			retry: &config.ExecRetry{
+				Attempts: 3,
+				When:     "ExitCode == 1",
+			},
+			exp:   []int{1, 2},
+			isErr: true,
+		},
+		{
+			title:     "delay",
+			exitCodes: []int{1, 1, 0},
+			retry: &config.ExecRetry{
+				Attempts: 3,
+				Delay:    20 * time.Millisecond,
+			},
+			exp:     []int{1, 1, 0},
+			minTime: 40 * time.Millisecond,
+		},
+		{
+			title:     "canceled during the delay",
+			exitCodes: []int{1, 0},
+			retry: &config.ExecRetry{
+				Attempts: 2,
+				Delay:    time.Hour,
+			},
+			canceled: true,
+			exp:      []int{1},
+			isErr:    true,
+		},
+	}
+	for _, d := range data {
+		d := d
+		t.Run(d.title, func(t *testing.T) {
+			t.Parallel()
+			executor := &sequenceExecutor{exitCodes: d.exitCodes}
+			ctrl := &ExecController{
+				Executor: executor,
+				Expr:     &expr.Expr{},
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if d.canceled {
+				time.AfterFunc(10*time.Millisecond, cancel)
+			}
+			startTime := time.Now()
+			result, err := ctrl.runWithRetry(ctx, &option.ExecOptions{
+				Args: []string{"make"},
+			}, &execRunSettings{
+				Retry: d.retry,
+				Stdin: strings.NewReader("input"),
+			})
+			if d.isErr {
+				require.NotNil(t, err)
+			} else {
+				require.Nil(t, err)
+			}
+			exitCodes := make([]int, len(result.Attempts))
+			for i, attempt := range result.Attempts {
+				require.Equal(t, i+1, attempt.Number)
+				exitCodes[i] = attempt.ExitCode
+			}
+			require.Equal(t, d.exp, exitCodes)
+			require.Equal(t, d.exp[len(d.exp)-1], result.ExitCode)
+			require.GreaterOrEqual(t, time.Since(startTime), d.minTime)
+			// every attempt reads the same standard input
+			for _, stdin := range executor.stdins {
+				require.Equal(t, "input", stdin)
+			}
+		})
+	}
+}
+
+func TestStdinReplayer_rewind(t *testing.T) {
+	t.Parallel()
+	p := filepath.Join(t.TempDir(), "stdin")
+	require.Nil(t, os.WriteFile(p, []byte("input"), 0o600))
+	f, err := os.Open(p)
+	require.Nil(t, err)
+	defer f.Close()
+	replayer := newStdinReplayer(f)
+	for i := 0; i < 2; i++ {
+		// the file is passed to the command as is, so the command reads it directly
+		b, err := io.ReadAll(f)
+		require.Nil(t, err)
+		require.Equal(t, "input", string(b))
+		require.Nil(t, replayer.rewind())
+	}
+}
@@ -0,0 +1,54 @@
+//go:build !windows
+
+package api
+
+import (
+	"context"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yuyaban/gitlab-comment/pkg/config"
+	"github.com/yuyaban/gitlab-comment/pkg/execute"
+	"github.com/yuyaban/gitlab-comment/pkg/expr"
+	"github.com/yuyaban/gitlab-comment/pkg/option"
+)
+
+func TestExecController_runWithRetry_pipe(t *testing.T) {
+	t.Parallel()
+	pr, pw, err := os.Pipe()
+	require.Nil(t, err)
+	defer pr.Close()
+	defer pw.Close()
+	ctrl := &ExecController{
+		Executor: &execute.Executor{
+			Stdout: io.Discard,
+			Stderr: io.Discard,
+		},
+		Expr: &expr.Expr{},
+	}
+	// the pipe is never closed, so gitlab-comment would block if it read the standard input
+	startTime := time.Now()
+	result, err := ctrl.runWithRetry(context.Background(), &option.ExecOptions{
+		Args: []string{"false"},
+	}, &execRunSettings{
+		Retry: &config.ExecRetry{
+			Attempts: 2,
+		},
+		Stdin:         pr,
+		MaxOutputSize: defaultMaxOutputSize,
+	})
+	require.NotNil(t, err)
+	require.Len(t, result.Attempts, 2)
+	require.Less(t, time.Since(startTime), 5*time.Second)
+	// data written after the command exits isn't consumed by gitlab-comment
+	_, err = pw.Write([]byte("after\n"))
+	require.Nil(t, err)
+	require.Nil(t, pr.SetReadDeadline(time.Now().Add(5*time.Second)))
+	b := make([]byte, 6)
+	_, err = io.ReadFull(pr, b)
+	require.Nil(t, err)
+	require.Equal(t, "after\n", string(b))
+}
@@ -0,0 +1,640 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yuyaban/gitlab-comment/pkg/config"
+	"github.com/yuyaban/gitlab-comment/pkg/execute"
+	"github.com/yuyaban/gitlab-comment/pkg/option"
+)
+
+const (
+	shell = "sh"
+	// defaultMaxOutputSize is the default maximum size of each captured output
+	defaultMaxOutputSize = 1 << 20
+)
+
+// execRunSettings are settings about how the command is run.
+// The command is run before an ExecConfig is selected by When,
+// so they are collected from all ExecConfigs of the template key.
+type execRunSettings struct {
+	Timeout time.Duration
+	Shell   string
+	Steps   []*config.ExecStep
+	Batch   *config.ExecBatch
+	// MaxOutputSize is the maximum size of each captured output. Negative means unlimited
+	MaxOutputSize int
+	// JUnit are glob patterns of JUnit XML reports
+	JUnit []string
+	// SARIF and Checkstyle are glob patterns of linters' reports
+	SARIF      []string
+	Checkstyle []string
+	// Terraform enables to parse the result of terraform plan. If it is nil, the result isn't parsed
+	Terraform *config.ExecTerraform
+	// Dir is the working directory of the command
+	Dir       string
+	StdinFile string
+	// Env are environment variables which are added to the command. The format is "<name>=<value>"
+	Env []string
+	// Retry is the policy to rerun the failed command
+	Retry *config.ExecRetry
+	// ExitCodeMap converts the command's exit code to gitlab-comment's exit code
+	ExitCodeMap map[int]int
+	// FailOn is a condition whether gitlab-comment fails
+	FailOn string
+	// CaptureFiles are files which are read after the command exits
+	CaptureFiles []*config.ExecCaptureFile
+	// Stdin is the standard input of the command. It is the file of StdinFile or ExecController.Stdin.
+	// Under a pseudo-terminal, it is nil unless it is a terminal or StdinFile is set
+	Stdin io.Reader
+	// Output receives the combined output while the command is running. It is used by the live mode
+	Output io.Writer
+}
+
+const (
+	batchFailAny = "any"
+	batchFailAll = "all"
+)
+
+func (settings *execRunSettings) validate(opts *option.ExecOptions) error {
+	if len(opts.Args) != 0 || settings.Shell != "" {
+		return nil
+	}
+	if len(settings.Steps) != 0 && settings.Batch != nil {
+		return errors.New("steps and batch can't be used at the same time")
+	}
+	if settings.Batch != nil {
+		if len(settings.Batch.Commands) == 0 {
+			return errors.New("batch commands are required")
+		}
+		switch settings.Batch.Fail {
+		case "", batchFailAny, batchFailAll:
+		default:
+			return errors.New(`batch fail must be either "any" or "all": ` + settings.Batch.Fail)
+		}
+		return nil
+	}
+	if len(settings.Steps) == 0 {
+		return errors.New("command is required")
+	}
+	return nil
+}
+
+// errRunSettingConflict is returned if entries of the template key have different settings about how the command runs.
+// They must be the same because the command runs before an entry is selected by when.
+var errRunSettingConflict = errors.New("conflicts with another entry of the template key. Settings about how the command runs must be the same in all entries because the command runs before when is evaluated")
+
+// merge merges run settings of execConfig into settings.
+// Timeout is the maximum of entries, and glob patterns of reports are merged.
+// Other settings can be set by multiple entries only if their values are the same.
+func (settings *execRunSettings) merge(execConfig *config.ExecConfig, env map[string]string) error { //nolint:cyclop,funlen,gocognit
+	conflict := func(name string) error {
+		return fmt.Errorf("%s %w", name, errRunSettingConflict)
+	}
+	if execConfig.Timeout > settings.Timeout {
+		settings.Timeout = execConfig.Timeout
+	}
+	if execConfig.Shell != "" {
+		if settings.Shell != "" && settings.Shell != execConfig.Shell {
+			return conflict("shell")
+		}
+		settings.Shell = execConfig.Shell
+	}
+	if execConfig.Steps != nil {
+		if settings.Steps != nil && !reflect.DeepEqual(settings.Steps, execConfig.Steps) {
+			return conflict("steps")
+		}
+		settings.Steps = execConfig.Steps
+	}
+	if execConfig.Batch != nil {
+		if settings.Batch != nil && !reflect.DeepEqual(settings.Batch, execConfig.Batch) {
+			return conflict("batch")
+		}
+		settings.Batch = execConfig.Batch
+	}
+	if execConfig.MaxOutputSize != 0 {
+		if settings.MaxOutputSize != 0 && settings.MaxOutputSize != execConfig.MaxOutputSize {
+			return conflict("max_output_size")
+		}
+		settings.MaxOutputSize = execConfig.MaxOutputSize
+	}
+	if execConfig.Terraform != nil && execConfig.Terraform.Enabled {
+		if settings.Terraform != nil && !reflect.DeepEqual(settings.Terraform, execConfig.Terraform) {
+			return conflict("terraform")
+		}
+		settings.Terraform = execConfig.Terraform
+	}
+	if execConfig.Dir != "" {
+		if settings.Dir != "" && settings.Dir != execConfig.Dir {
+			return conflict("dir")
+		}
+		settings.Dir = execConfig.Dir
+	}
+	if execConfig.StdinFile != "" {
+		if settings.StdinFile != "" && settings.StdinFile != execConfig.StdinFile {
+			return conflict("stdin_file")
+		}
+		settings.StdinFile = execConfig.StdinFile
+	}
+	if execConfig.Retry != nil {
+		if settings.Retry != nil && !reflect.DeepEqual(settings.Retry, execConfig.Retry) {
+			return conflict("retry")
+		}
+		settings.Retry = execConfig.Retry
+	}
+	if execConfig.ExitCodeMap != nil {
+		if settings.ExitCodeMap != nil && !reflect.DeepEqual(settings.ExitCodeMap, execConfig.ExitCodeMap) {
+			return conflict("exit_code_map")
+		}
+		settings.ExitCodeMap = execConfig.ExitCodeMap
+	}
+	if execConfig.FailOn != "" {
+		if settings.FailOn != "" && settings.FailOn != execConfig.FailOn {
+			return conflict("fail_on")
+		}
+		settings.FailOn = execConfig.FailOn
+	}
+	for k, v := range execConfig.Env {
+		if a, ok := env[k]; ok && a != v {
+			return conflict("env." + k)
+		}
+		env[k] = v
+	}
+	settings.JUnit = appendUnique(settings.JUnit, execConfig.JUnit...)
+	settings.SARIF = appendUnique(settings.SARIF, execConfig.SARIF...)
+	settings.Checkstyle = appendUnique(settings.Checkstyle, execConfig.Checkstyle...)
+	captureFiles, err := appendCaptureFiles(settings.CaptureFiles, execConfig.CaptureFiles)
+	if err != nil {
+		return err
+	}
+	settings.CaptureFiles = captureFiles
+	return nil
+}
+
+// getExecRunSettings collects settings from ExecConfigs of the template key and command line options.
+// Command line options take precedence over the configuration file.
+func getExecRunSettings(cfg *config.Config, opts *option.ExecOptions) (*execRunSettings, error) { //nolint:cyclop,funlen
+	settings := &execRunSettings{}
+	env := map[string]string{}
+	if opts.Template == "" {
+		for i, execConfig := range cfg.Exec[opts.TemplateKey] {
+			if err := settings.merge(execConfig, env); err != nil {
+				return nil, fmt.Errorf("exec.%s[%d]: %w", opts.TemplateKey, i, err)
+			}
+		}
+	}
+	if opts.RetryAttempts != 0 {
+		retry := &config.ExecRetry{}
+		if settings.Retry != nil {
+			*retry = *settings.Retry
+		}
+		retry.Attempts = opts.RetryAttempts
+		if opts.RetryDelay != 0 {
+			retry.Delay = opts.RetryDelay
+		}
+		settings.Retry = retry
+	}
+	if opts.Dir != "" {
+		settings.Dir = opts.Dir
+	}
+	if opts.StdinFile != "" {
+		settings.StdinFile = opts.StdinFile
+	}
+	for k, v := range opts.Env {
+		env[k] = v
+	}
+	for k, v := range env {
+		settings.Env = append(settings.Env, k+"="+v)
+	}
+	sort.Strings(settings.Env)
+	settings.JUnit = appendUnique(settings.JUnit, opts.JUnit...)
+	settings.SARIF = appendUnique(settings.SARIF, opts.SARIF...)
+	settings.Checkstyle = appendUnique(settings.Checkstyle, opts.Checkstyle...)
+	if opts.Terraform || opts.TerraformPlanJSON != "" {
+		settings.Terraform = &config.ExecTerraform{
+			Enabled:  true,
+			PlanJSON: opts.TerraformPlanJSON,
+		}
+	}
+	if opts.MaxOutputSize != 0 {
+		settings.MaxOutputSize = opts.MaxOutputSize
+	}
+	if settings.MaxOutputSize == 0 {
+		settings.MaxOutputSize = defaultMaxOutputSize
+		// the result of terraform plan is parsed from the whole output, so the output isn't cut by default
+		if settings.Terraform != nil && settings.Terraform.PlanJSON == "" {
+			settings.MaxOutputSize = -1
+		}
+	}
+	if opts.Timeout != 0 {
+		settings.Timeout = opts.Timeout
+	}
+	if len(opts.Batch) != 0 {
+		commands, err := parseBatchFlag(opts.Batch)
+		if err != nil {
+			return nil, err
+		}
+		settings.Batch = &config.ExecBatch{
+			Commands: commands,
+		}
+		settings.Shell = ""
+		settings.Steps = nil
+	}
+	if settings.Batch != nil && (opts.Parallelism != 0 || opts.BatchFail != "") {
+		batch := *settings.Batch
+		if opts.Parallelism != 0 {
+			batch.Parallelism = opts.Parallelism
+		}
+		if opts.BatchFail != "" {
+			batch.Fail = opts.BatchFail
+		}
+		settings.Batch = &batch
+	}
+	if opts.Shell != "" {
+		settings.Shell = opts.Shell
+		settings.Steps = nil
+		settings.Batch = nil
+	}
+	if len(opts.Args) != 0 {
+		settings.Shell = ""
+		settings.Steps = nil
+		settings.Batch = nil
+	}
+	return settings, nil
+}
+
+// joinCommand returns the command which is shown in comments.
+func (settings *execRunSettings) joinCommand(opts *option.ExecOptions) string {
+	switch {
+	case len(opts.Args) != 0:
+		return strings.Join(opts.Args, " ")
+	case settings.Shell != "":
+		return strings.TrimSpace(settings.Shell)
+	case len(settings.Steps) != 0:
+		cmds := make([]string, len(settings.Steps))
+		for i, step := range settings.Steps {
+			cmds[i] = strings.TrimSpace(step.Run)
+		}
+		return strings.Join(cmds, "\n")
+	case settings.Batch != nil:
+		cmds := make([]string, len(settings.Batch.Commands))
+		for i, command := range settings.Batch.Commands {
+			name := command.Name
+			if name == "" {
+				name = fmt.Sprintf("command %d", i+1)
+			}
+			cmds[i] = name + ": " + strings.TrimSpace(command.Run)
+		}
+		return strings.Join(cmds, "\n")
+	default:
+		return ""
+	}
+}
+
+// resolveDir resolves dir from base. If dir is empty, base is returned.
+func resolveDir(base, dir string) string {
+	if dir == "" {
+		return base
+	}
+	if base == "" || filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(base, dir)
+}
+
+func appendUnique(list []string, elems ...string) []string {
+	for _, elem := range elems {
+		if !contains(list, elem) {
+			list = append(list, elem)
+		}
+	}
+	return list
+}
+
+func parseBatchFlag(batch []string) ([]*config.ExecStep, error) {
+	commands := make([]*config.ExecStep, len(batch))
+	for i, b := range batch {
+		a := strings.SplitN(b, ":", 2) //nolint:gomnd
+		if len(a) < 2 {                //nolint:gomnd
+			return nil, errors.New("invalid batch flag. The format should be '--batch <name>:<shell script>'")
+		}
+		commands[i] = &config.ExecStep{
+			Name: a[0],
+			Run:  a[1],
+		}
+	}
+	return commands, nil
+}
+
+type StepResult struct {
+	Name        string
+	JoinCommand string
+	// Dir is the working directory of the step. If it is empty, the current directory is used
+	Dir            string
+	ExitCode       int
+	Stdout         string
+	Stderr         string
+	CombinedOutput string
+	// RawCombinedOutput is the combined output including ANSI escape sequences
+	RawCombinedOutput string
+	// Output is lines of the step's output. Elapsed is the duration from the start of the step
+	Output    execute.Output
+	StartTime time.Time
+	Duration  time.Duration
+	TimedOut  bool
+	// UserTime and SystemTime are CPU times of the step
+	UserTime   time.Duration
+	SystemTime time.Duration
+	// MaxRSS is the peak resident set size of the step in bytes
+	MaxRSS int64
+	// Signal is the name of the signal which terminated the step
+	Signal string
+	// Skipped is true if the step isn't run because a previous step failed
+	Skipped bool
+}
+
+// addTruncation adds the truncation of src to dst.
+func addTruncation(dst, src *execute.Result) {
+	dst.StdoutTruncated = dst.StdoutTruncated || src.StdoutTruncated
+	dst.StderrTruncated = dst.StderrTruncated || src.StderrTruncated
+	dst.CombinedOutputTruncated = dst.CombinedOutputTruncated || src.CombinedOutputTruncated
+	dst.StdoutOmittedBytes += src.StdoutOmittedBytes
+	dst.StderrOmittedBytes += src.StderrOmittedBytes
+	dst.CombinedOutputOmittedBytes += src.CombinedOutputOmittedBytes
+	dst.OutputOmittedBytes += src.OutputOmittedBytes
+}
+
+// addUsage adds the resource usage of src to dst.
+// CPU times are summed up and the peak resident set size is the maximum of them.
+func addUsage(dst, src *execute.Result) {
+	dst.UserTime += src.UserTime
+	dst.SystemTime += src.SystemTime
+	if src.MaxRSS > dst.MaxRSS {
+		dst.MaxRSS = src.MaxRSS
+	}
+}
+
+// setStepUsage sets the timing and the resource usage of result to stepResult.
+func setStepUsage(stepResult *StepResult, result *execute.Result) {
+	stepResult.StartTime = result.StartTime
+	stepResult.Duration = result.Duration
+	stepResult.TimedOut = result.TimedOut
+	stepResult.UserTime = result.UserTime
+	stepResult.SystemTime = result.SystemTime
+	stepResult.MaxRSS = result.MaxRSS
+	stepResult.Signal = result.Signal
+}
+
+type runResult struct {
+	*execute.Result
+	JoinCommand string
+	Steps       []*StepResult
+	Attempts    []*Attempt
+	MaxAttempts int
+}
+
+// run runs the command arguments, the shell script, steps, or batch commands.
+func (ctrl *ExecController) run(ctx context.Context, opts *option.ExecOptions, settings *execRunSettings) (*runResult, error) {
+	switch {
+	case len(opts.Args) != 0:
+		result, err := ctrl.Executor.Run(ctx, &execute.Params{
+			Cmd:           opts.Args[0],
+			Args:          opts.Args[1:],
+			Stdin:         settings.Stdin,
+			Timeout:       settings.Timeout,
+			GracePeriod:   opts.GracePeriod,
+			MaxOutputSize: settings.MaxOutputSize,
+			Output:        settings.Output,
+			Dir:           settings.Dir,
+			Env:           settings.Env,
+			PTY:           opts.PTY,
+		})
+		return &runResult{
+			Result:      result,
+			JoinCommand: settings.joinCommand(opts),
+		}, err
+	case settings.Shell != "":
+		result, err := ctrl.Executor.Run(ctx, &execute.Params{
+			Cmd:           shell,
+			Args:          []string{"-c", settings.Shell},
+			Stdin:         settings.Stdin,
+			Timeout:       settings.Timeout,
+			GracePeriod:   opts.GracePeriod,
+			MaxOutputSize: settings.MaxOutputSize,
+			Output:        settings.Output,
+			Dir:           settings.Dir,
+			Env:           settings.Env,
+			PTY:           opts.PTY,
+		})
+		return &runResult{
+			Result:      result,
+			JoinCommand: settings.joinCommand(opts),
+		}, err
+	case len(settings.Steps) != 0:
+		return ctrl.runSteps(ctx, opts, settings)
+	case settings.Batch != nil:
+		return ctrl.runBatch(ctx, opts, settings)
+	default:
+		return nil, errors.New("command is required")
+	}
+}
+
+// runSteps runs steps sequentially. If a step fails, the remaining steps are skipped.
+// The timeout is applied to all steps.
+func (ctrl *ExecController) runSteps(ctx context.Context, opts *option.ExecOptions, settings *execRunSettings) (*runResult, error) { //nolint:funlen
+	ret := &runResult{
+		Result: &execute.Result{},
+		Steps:  make([]*StepResult, len(settings.Steps)),
+	}
+	var stdout, stderr, combinedOutput, rawCombinedOutput, cmds, joinCommands []string
+	var runErr error
+	for i, step := range settings.Steps {
+		name := step.Name
+		if name == "" {
+			name = fmt.Sprintf("step %d", i+1)
+		}
+		stepResult := &StepResult{
+			Name:        name,
+			JoinCommand: strings.TrimSpace(step.Run),
+			Dir:         resolveDir(settings.Dir, step.Dir),
+		}
+		ret.Steps[i] = stepResult
+		joinCommands = append(joinCommands, stepResult.JoinCommand)
+		if runErr != nil {
+			stepResult.Skipped = true
+			continue
+		}
+		stepTimeout := time.Duration(0)
+		if settings.Timeout > 0 {
+			stepTimeout = settings.Timeout - ret.Duration
+			if stepTimeout <= 0 {
+				stepTimeout = time.Nanosecond
+			}
+		}
+		result, err := ctrl.Executor.Run(ctx, &execute.Params{
+			Cmd:           shell,
+			Args:          []string{"-c", step.Run},
+			Stdin:         settings.Stdin,
+			Timeout:       stepTimeout,
+			GracePeriod:   opts.GracePeriod,
+			MaxOutputSize: settings.MaxOutputSize,
+			Output:        settings.Output,
+			Dir:           stepResult.Dir,
+			Env:           settings.Env,
+			PTY:           opts.PTY,
+		})
+		stepResult.ExitCode = result.ExitCode
+		stepResult.Stdout = result.Stdout
+		stepResult.Stderr = result.Stderr
+		stepResult.CombinedOutput = result.CombinedOutput
+		stepResult.RawCombinedOutput = result.RawCombinedOutput
+		stepResult.Output = result.Output
+		setStepUsage(stepResult, result)
+
+		if ret.StartTime.IsZero() {
+			ret.StartTime = result.StartTime
+		}
+		ret.Output = append(ret.Output, result.Output.Shift(result.StartTime.Sub(ret.StartTime))...)
+		ret.EndTime = result.EndTime
+		ret.Duration += result.Duration
+		addTruncation(ret.Result, result)
+		addUsage(ret.Result, result)
+		cmds = append(cmds, result.Cmd)
+		stdout = append(stdout, result.Stdout)
+		stderr = append(stderr, result.Stderr)
+		combinedOutput = append(combinedOutput, result.CombinedOutput)
+		rawCombinedOutput = append(rawCombinedOutput, result.RawCombinedOutput)
+		if err != nil {
+			ret.ExitCode = result.ExitCode
+			ret.TimedOut = result.TimedOut
+			ret.Canceled = result.Canceled
+			ret.Signal = result.Signal
+			runErr = fmt.Errorf("run a step %s: %w", name, err)
+		}
+	}
+	ret.Cmd = strings.Join(cmds, "\n")
+	ret.Stdout = strings.Join(stdout, "")
+	ret.Stderr = strings.Join(stderr, "")
+	ret.CombinedOutput = strings.Join(combinedOutput, "")
+	ret.RawCombinedOutput = strings.Join(rawCombinedOutput, "")
+	ret.JoinCommand = strings.Join(joinCommands, "\n")
+	return ret, runErr
+}
+
+// runBatch runs commands concurrently.
+// Outputs of each command are buffered and written to the standard output when the command exits,
+// so that outputs of commands aren't interleaved.
+func (ctrl *ExecController) runBatch(ctx context.Context, opts *option.ExecOptions, settings *execRunSettings) (*runResult, error) { //nolint:funlen,cyclop
+	batch := settings.Batch
+	parallelism := batch.Parallelism
+	if parallelism <= 0 {
+		parallelism = len(batch.Commands)
+	}
+	ret := &runResult{
+		Result: &execute.Result{},
+		Steps:  make([]*StepResult, len(batch.Commands)),
+	}
+	errs := make([]error, len(batch.Commands))
+	sem := make(chan struct{}, parallelism)
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	startTime := time.Now()
+	for i, command := range batch.Commands {
+		name := command.Name
+		if name == "" {
+			name = fmt.Sprintf("command %d", i+1)
+		}
+		stepResult := &StepResult{
+			Name:        name,
+			JoinCommand: strings.TrimSpace(command.Run),
+			Dir:         resolveDir(settings.Dir, command.Dir),
+		}
+		ret.Steps[i] = stepResult
+		wg.Add(1)
+		go func(i int, command *config.ExecStep) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() {
+				<-sem
+			}()
+			result, err := ctrl.Executor.Run(ctx, &execute.Params{
+				Cmd:           shell,
+				Args:          []string{"-c", command.Run},
+				Timeout:       settings.Timeout,
+				GracePeriod:   opts.GracePeriod,
+				MaxOutputSize: settings.MaxOutputSize,
+				Output:        settings.Output,
+				Dir:           stepResult.Dir,
+				Env:           settings.Env,
+				PTY:           opts.PTY,
+				Stdout:        io.Discard,
+				Stderr:        io.Discard,
+			})
+			stepResult.ExitCode = result.ExitCode
+			stepResult.Stdout = result.Stdout
+			stepResult.Stderr = result.Stderr
+			stepResult.CombinedOutput = result.CombinedOutput
+			stepResult.RawCombinedOutput = result.RawCombinedOutput
+			stepResult.Output = result.Output
+			setStepUsage(stepResult, result)
+			if err != nil {
+				errs[i] = fmt.Errorf("run a batch command %s: %w", stepResult.Name, err)
+			}
+			mutex.Lock()
+			defer mutex.Unlock()
+			addTruncation(ret.Result, result)
+			addUsage(ret.Result, result)
+			ret.Canceled = ret.Canceled || result.Canceled
+			fmt.Fprintf(ctrl.Stdout, "==> %s (exit code: %d)\n%s", stepResult.Name, stepResult.ExitCode, stepResult.CombinedOutput)
+		}(i, command)
+	}
+	wg.Wait()
+	ret.StartTime = startTime
+	ret.EndTime = time.Now()
+	ret.Duration = ret.EndTime.Sub(startTime)
+
+	var stdout, stderr, combinedOutput, rawCombinedOutput, joinCommands []string
+	var firstErr error
+	failures := 0
+	for i, stepResult := range ret.Steps {
+		stdout = append(stdout, stepResult.Stdout)
+		stderr = append(stderr, stepResult.Stderr)
+		combinedOutput = append(combinedOutput, stepResult.CombinedOutput)
+		rawCombinedOutput = append(rawCombinedOutput, stepResult.RawCombinedOutput)
+		ret.Output = append(ret.Output, stepResult.Output.Shift(stepResult.StartTime.Sub(startTime))...)
+		joinCommands = append(joinCommands, stepResult.Name+": "+stepResult.JoinCommand)
+		if errs[i] == nil {
+			continue
+		}
+		failures++
+		if firstErr == nil {
+			firstErr = errs[i]
+			ret.ExitCode = stepResult.ExitCode
+			ret.TimedOut = stepResult.TimedOut
+			ret.Signal = stepResult.Signal
+		}
+	}
+	ret.Cmd = strings.Join(joinCommands, "\n")
+	ret.JoinCommand = ret.Cmd
+	ret.Stdout = strings.Join(stdout, "")
+	ret.Stderr = strings.Join(stderr, "")
+	ret.CombinedOutput = strings.Join(combinedOutput, "")
+	ret.RawCombinedOutput = strings.Join(rawCombinedOutput, "")
+	ret.Output.SortByElapsed()
+	if failures == 0 || (batch.Fail == batchFailAll && failures < len(ret.Steps)) {
+		ret.ExitCode = 0
+		ret.TimedOut = false
+		ret.Signal = ""
+		return ret, nil
+	}
+	return ret, firstErr
+}
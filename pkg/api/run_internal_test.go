@@ -0,0 +1,451 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yuyaban/gitlab-comment/pkg/config"
+	"github.com/yuyaban/gitlab-comment/pkg/execute"
+	"github.com/yuyaban/gitlab-comment/pkg/option"
+)
+
+// scriptExecutor returns the result of the script given by "sh -c <script>".
+// It records parameters of runs and the maximum number of commands which run concurrently.
+type scriptExecutor struct {
+	mutex      sync.Mutex
+	results    map[string]*execute.Result
+	delay      time.Duration
+	params     []*execute.Params
+	running    int
+	maxRunning int
+}
+
+func (executor *scriptExecutor) Run(ctx context.Context, params *execute.Params) (*execute.Result, error) {
+	executor.mutex.Lock()
+	executor.params = append(executor.params, params)
+	executor.running++
+	if executor.running > executor.maxRunning {
+		executor.maxRunning = executor.running
+	}
+	executor.mutex.Unlock()
+	defer func() {
+		executor.mutex.Lock()
+		executor.running--
+		executor.mutex.Unlock()
+	}()
+	if executor.delay > 0 {
+		time.Sleep(executor.delay)
+	}
+	script := params.Args[1]
+	result := *executor.results[script]
+	result.Cmd = script
+	if result.ExitCode != 0 {
+		return &result, errors.New("exit status")
+	}
+	return &result, nil
+}
+
+func TestExecController_runSteps(t *testing.T) { //nolint:funlen
+	t.Parallel()
+	startTime := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	data := []struct {
+		title    string
+		settings *execRunSettings
+		results  map[string]*execute.Result
+		// timeouts and dirs are parameters of steps which are run
+		timeouts []time.Duration
+		dirs     []string
+		exp      *runResult
+		isErr    bool
+	}{
+		{
+			title: "outputs are joined and times are shifted",
+			settings: &execRunSettings{
+				Dir: "/repo",
+				Steps: []*config.ExecStep{
+					{Name: "build", Run: "make build\n", Dir: "app"},
+					{Run: "make test", Dir: "/tmp"},
+				},
+			},
+			results: map[string]*execute.Result{
+				"make build\n": {
+					Stdout:            "build\n",
+					Stderr:            "warn\n",
+					CombinedOutput:    "build\nwarn\n",
+					RawCombinedOutput: "\x1b[1mbuild\x1b[0m\nwarn\n",
+					StartTime:         startTime,
+					EndTime:           startTime.Add(3 * time.Second),
+					Duration:          3 * time.Second,
+					Output: execute.Output{
+						{Stream: execute.StreamStdout, Elapsed: time.Second, Text: "build"},
+						{Stream: execute.StreamStderr, Elapsed: 2 * time.Second, Text: "warn"},
+					},
+				},
+				"make test": {
+					Stdout:            "test\n",
+					CombinedOutput:    "test\n",
+					RawCombinedOutput: "test\n",
+					StartTime:         startTime.Add(4 * time.Second),
+					EndTime:           startTime.Add(6 * time.Second),
+					Duration:          2 * time.Second,
+					Output: execute.Output{
+						{Stream: execute.StreamStdout, Elapsed: time.Second, Text: "test"},
+					},
+				},
+			},
+			timeouts: []time.Duration{0, 0},
+			dirs:     []string{"/repo/app", "/tmp"},
+			exp: &runResult{
+				Result: &execute.Result{
+					Cmd:               "make build\n\nmake test",
+					Stdout:            "build\ntest\n",
+					Stderr:            "warn\n",
+					CombinedOutput:    "build\nwarn\ntest\n",
+					RawCombinedOutput: "\x1b[1mbuild\x1b[0m\nwarn\ntest\n",
+					StartTime:         startTime,
+					EndTime:           startTime.Add(6 * time.Second),
+					Duration:          5 * time.Second,
+					Output: execute.Output{
+						{Stream: execute.StreamStdout, Elapsed: time.Second, Text: "build"},
+						{Stream: execute.StreamStderr, Elapsed: 2 * time.Second, Text: "warn"},
+						{Stream: execute.StreamStdout, Elapsed: 5 * time.Second, Text: "test"},
+					},
+				},
+				JoinCommand: "make build\nmake test",
+				Steps: []*StepResult{
+					{
+						Name:              "build",
+						JoinCommand:       "make build",
+						Dir:               "/repo/app",
+						Stdout:            "build\n",
+						Stderr:            "warn\n",
+						CombinedOutput:    "build\nwarn\n",
+						RawCombinedOutput: "\x1b[1mbuild\x1b[0m\nwarn\n",
+						StartTime:         startTime,
+						Duration:          3 * time.Second,
+						Output: execute.Output{
+							{Stream: execute.StreamStdout, Elapsed: time.Second, Text: "build"},
+							{Stream: execute.StreamStderr, Elapsed: 2 * time.Second, Text: "warn"},
+						},
+					},
+					{
+						Name:              "step 2",
+						JoinCommand:       "make test",
+						Dir:               "/tmp",
+						Stdout:            "test\n",
+						CombinedOutput:    "test\n",
+						RawCombinedOutput: "test\n",
+						StartTime:         startTime.Add(4 * time.Second),
+						Duration:          2 * time.Second,
+						Output: execute.Output{
+							{Stream: execute.StreamStdout, Elapsed: time.Second, Text: "test"},
+						},
+					},
+				},
+			},
+		},
+		{
+			title: "later steps are skipped after a failure",
+			settings: &execRunSettings{
+				Steps: []*config.ExecStep{
+					{Run: "false"},
+					{Run: "true"},
+				},
+			},
+			results: map[string]*execute.Result{
+				"false": {
+					ExitCode:  1,
+					StartTime: startTime,
+					EndTime:   startTime.Add(time.Second),
+					Duration:  time.Second,
+				},
+			},
+			timeouts: []time.Duration{0},
+			dirs:     []string{""},
+			exp: &runResult{
+				Result: &execute.Result{
+					ExitCode:  1,
+					Cmd:       "false",
+					StartTime: startTime,
+					EndTime:   startTime.Add(time.Second),
+					Duration:  time.Second,
+				},
+				JoinCommand: "false\ntrue",
+				Steps: []*StepResult{
+					{
+						Name:        "step 1",
+						JoinCommand: "false",
+						ExitCode:    1,
+						StartTime:   startTime,
+						Duration:    time.Second,
+					},
+					{
+						Name:        "step 2",
+						JoinCommand: "true",
+						Skipped:     true,
+					},
+				},
+			},
+			isErr: true,
+		},
+		{
+			title: "steps share the timeout",
+			settings: &execRunSettings{
+				Timeout: 10 * time.Second,
+				Steps: []*config.ExecStep{
+					{Run: "step1"},
+					{Run: "step2"},
+					{Run: "step3"},
+				},
+			},
+			results: map[string]*execute.Result{
+				"step1": {StartTime: startTime, Duration: 4 * time.Second},
+				"step2": {StartTime: startTime.Add(4 * time.Second), Duration: 6 * time.Second},
+				"step3": {StartTime: startTime.Add(10 * time.Second)},
+			},
+			// the budget is used up, but the step still times out instead of running without the timeout
+			timeouts: []time.Duration{10 * time.Second, 6 * time.Second, time.Nanosecond},
+			dirs:     []string{"", "", ""},
+		},
+		{
+			title: "timed out",
+			settings: &execRunSettings{
+				Timeout: time.Second,
+				Steps: []*config.ExecStep{
+					{Run: "sleep 10"},
+					{Run: "true"},
+				},
+			},
+			results: map[string]*execute.Result{
+				"sleep 10": {
+					ExitCode:  124,
+					TimedOut:  true,
+					Signal:    "SIGTERM",
+					StartTime: startTime,
+					Duration:  time.Second,
+				},
+			},
+			timeouts: []time.Duration{time.Second},
+			dirs:     []string{""},
+			exp: &runResult{
+				Result: &execute.Result{
+					ExitCode:  124,
+					TimedOut:  true,
+					Signal:    "SIGTERM",
+					Cmd:       "sleep 10",
+					StartTime: startTime,
+					Duration:  time.Second,
+				},
+				JoinCommand: "sleep 10\ntrue",
+				Steps: []*StepResult{
+					{
+						Name:        "step 1",
+						JoinCommand: "sleep 10",
+						ExitCode:    124,
+						TimedOut:    true,
+						Signal:      "SIGTERM",
+						StartTime:   startTime,
+						Duration:    time.Second,
+					},
+					{
+						Name:        "step 2",
+						JoinCommand: "true",
+						Skipped:     true,
+					},
+				},
+			},
+			isErr: true,
+		},
+	}
+	for _, d := range data {
+		d := d
+		t.Run(d.title, func(t *testing.T) {
+			t.Parallel()
+			executor := &scriptExecutor{results: d.results}
+			ctrl := &ExecController{
+				Executor: executor,
+			}
+			result, err := ctrl.runSteps(context.Background(), &option.ExecOptions{}, d.settings)
+			if d.isErr {
+				require.NotNil(t, err)
+			} else {
+				require.Nil(t, err)
+			}
+			timeouts := make([]time.Duration, len(executor.params))
+			dirs := make([]string, len(executor.params))
+			for i, params := range executor.params {
+				timeouts[i] = params.Timeout
+				dirs[i] = params.Dir
+			}
+			require.Equal(t, d.timeouts, timeouts)
+			require.Equal(t, d.dirs, dirs)
+			if d.exp != nil {
+				require.Equal(t, d.exp, result)
+			}
+		})
+	}
+}
+
+func TestExecController_runBatch(t *testing.T) { //nolint:funlen
+	t.Parallel()
+	results := map[string]*execute.Result{
+		"lint": {
+			Stdout:         "lint\n",
+			CombinedOutput: "lint\n",
+		},
+		"test": {
+			ExitCode:       2,
+			Stderr:         "fail\n",
+			CombinedOutput: "fail\n",
+		},
+		"build": {
+			ExitCode:       124,
+			TimedOut:       true,
+			Signal:         "SIGTERM",
+			CombinedOutput: "build\n",
+		},
+		"deploy": {
+			ExitCode: 130,
+			Canceled: true,
+			Signal:   "SIGINT",
+		},
+	}
+	data := []struct {
+		title       string
+		batch       *config.ExecBatch
+		maxRunning  int
+		exitCode    int
+		timedOut    bool
+		canceled    bool
+		signal      string
+		stepResults []*StepResult
+		isErr       bool
+	}{
+		{
+			title: "succeeded",
+			batch: &config.ExecBatch{
+				Commands: []*config.ExecStep{
+					{Name: "lint", Run: "lint"},
+					{Run: "lint"},
+				},
+			},
+			maxRunning: 2,
+			stepResults: []*StepResult{
+				{Name: "lint", JoinCommand: "lint", Stdout: "lint\n", CombinedOutput: "lint\n"},
+				{Name: "command 2", JoinCommand: "lint", Stdout: "lint\n", CombinedOutput: "lint\n"},
+			},
+		},
+		{
+			title: "parallelism",
+			batch: &config.ExecBatch{
+				Parallelism: 2,
+				Commands: []*config.ExecStep{
+					{Run: "lint"},
+					{Run: "lint"},
+					{Run: "lint"},
+					{Run: "lint"},
+				},
+			},
+			maxRunning: 2,
+		},
+		{
+			title: "any fails with the first failed command",
+			batch: &config.ExecBatch{
+				Parallelism: 1,
+				Commands: []*config.ExecStep{
+					{Name: "lint", Run: "lint"},
+					{Name: "build", Run: "build"},
+					{Name: "test", Run: "test"},
+				},
+			},
+			maxRunning: 1,
+			exitCode:   124,
+			timedOut:   true,
+			signal:     "SIGTERM",
+			stepResults: []*StepResult{
+				{Name: "lint", JoinCommand: "lint", Stdout: "lint\n", CombinedOutput: "lint\n"},
+				{Name: "build", JoinCommand: "build", ExitCode: 124, TimedOut: true, Signal: "SIGTERM", CombinedOutput: "build\n"},
+				{Name: "test", JoinCommand: "test", ExitCode: 2, Stderr: "fail\n", CombinedOutput: "fail\n"},
+			},
+			isErr: true,
+		},
+		{
+			title: "all succeeds if some commands succeed",
+			batch: &config.ExecBatch{
+				Fail: batchFailAll,
+				Commands: []*config.ExecStep{
+					{Run: "lint"},
+					{Run: "test"},
+				},
+			},
+			maxRunning: 2,
+		},
+		{
+			title: "all fails if all commands fail",
+			batch: &config.ExecBatch{
+				Fail: batchFailAll,
+				Commands: []*config.ExecStep{
+					{Run: "test"},
+					{Run: "build"},
+				},
+			},
+			maxRunning: 2,
+			exitCode:   2,
+			isErr:      true,
+		},
+		{
+			title: "canceled",
+			batch: &config.ExecBatch{
+				Fail: batchFailAll,
+				Commands: []*config.ExecStep{
+					{Run: "lint"},
+					{Run: "deploy"},
+				},
+			},
+			maxRunning: 2,
+			canceled:   true,
+		},
+	}
+	for _, d := range data {
+		d := d
+		t.Run(d.title, func(t *testing.T) {
+			t.Parallel()
+			executor := &scriptExecutor{
+				results: results,
+				delay:   50 * time.Millisecond,
+			}
+			stdout := &bytes.Buffer{}
+			ctrl := &ExecController{
+				Stdout:   stdout,
+				Executor: executor,
+			}
+			result, err := ctrl.runBatch(context.Background(), &option.ExecOptions{}, &execRunSettings{
+				Batch: d.batch,
+			})
+			if d.isErr {
+				require.NotNil(t, err)
+			} else {
+				require.Nil(t, err)
+			}
+			require.Equal(t, d.maxRunning, executor.maxRunning)
+			require.Equal(t, d.exitCode, result.ExitCode)
+			require.Equal(t, d.timedOut, result.TimedOut)
+			require.Equal(t, d.canceled, result.Canceled)
+			require.Equal(t, d.signal, result.Signal)
+			require.Len(t, result.Steps, len(d.batch.Commands))
+			if d.stepResults != nil {
+				require.Equal(t, d.stepResults, result.Steps)
+			}
+			// the output of each command is written when it exits
+			for _, stepResult := range result.Steps {
+				require.Contains(t, stdout.String(), fmt.Sprintf("==> %s (exit code: %d)\n%s", stepResult.Name, stepResult.ExitCode, stepResult.CombinedOutput))
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"io"
+	"time"
 
 	"github.com/urfave/cli/v2"
 )
@@ -63,8 +64,9 @@ func (runner *Runner) Run(ctx context.Context, args []string) error { //nolint:f
 						Value:   "default",
 					},
 					&cli.StringFlag{
-						Name:  "config",
-						Usage: "configuration file path",
+						Name:    "config",
+						Usage:   "configuration file path",
+						EnvVars: []string{"GITLAB_COMMENT_CONFIG"},
 					},
 					&cli.IntFlag{
 						Name:  "mr",
@@ -137,8 +139,9 @@ func (runner *Runner) Run(ctx context.Context, args []string) error { //nolint:f
 						Value:   "default",
 					},
 					&cli.StringFlag{
-						Name:  "config",
-						Usage: "configuration file path",
+						Name:    "config",
+						Usage:   "configuration file path",
+						EnvVars: []string{"GITLAB_COMMENT_CONFIG"},
 					},
 					&cli.IntFlag{
 						Name:  "mr",
@@ -172,12 +175,160 @@ func (runner *Runner) Run(ctx context.Context, args []string) error { //nolint:f
 						Aliases: []string{"u"},
 						Usage:   "update the comment that matches with the condition",
 					},
+					&cli.DurationFlag{
+						Name:  "timeout",
+						Usage: "timeout of the command (e.g. 10m). SIGTERM is sent to the command when it times out",
+					},
+					&cli.DurationFlag{
+						Name:  "grace-period",
+						Usage: "duration from SIGTERM to SIGKILL when the command times out",
+						Value: 10 * time.Second, //nolint:gomnd
+					},
+					&cli.StringFlag{
+						Name:  "shell",
+						Usage: "shell script which is run with 'sh -c' instead of the command arguments",
+					},
+					&cli.StringSliceFlag{
+						Name:  "batch",
+						Usage: "command which is run concurrently with other batch commands. The format is '<name>:<shell script>'",
+					},
+					&cli.IntFlag{
+						Name:  "parallelism",
+						Usage: "the maximum number of batch commands which run at the same time. 0 means unlimited",
+					},
+					&cli.StringFlag{
+						Name:  "batch-fail",
+						Usage: "exit code policy of batch commands. 'any' fails if any command fails, 'all' fails only if all commands fail",
+					},
+					&cli.IntFlag{
+						Name:  "max-output-size",
+						Usage: "the maximum size of each captured output in bytes. The head and the tail are kept and the middle is omitted. Negative means unlimited (default: 1MiB, or unlimited if the output is parsed by --terraform)",
+					},
+					&cli.BoolFlag{
+						Name:  "live",
+						Usage: "create a note when the command starts and update it with the latest output periodically. The note is replaced with the result when the command exits regardless of the update condition",
+					},
+					&cli.DurationFlag{
+						Name:  "live-interval",
+						Usage: "interval of updating the live note. The minimum is 5s",
+						Value: 30 * time.Second, //nolint:gomnd
+					},
+					&cli.StringSliceFlag{
+						Name:  "junit",
+						Usage: "glob pattern of JUnit XML reports. The parsed result is available as .Tests in templates",
+					},
+					&cli.StringSliceFlag{
+						Name:  "sarif",
+						Usage: "glob pattern of SARIF reports. The parsed result is available as .Findings in templates",
+					},
+					&cli.StringSliceFlag{
+						Name:  "checkstyle",
+						Usage: "glob pattern of Checkstyle XML reports. The parsed result is available as .Findings in templates",
+					},
+					&cli.BoolFlag{
+						Name:  "terraform",
+						Usage: "parse the output of terraform plan or terraform show -json. The parsed result is available as .Terraform in templates",
+					},
+					&cli.StringFlag{
+						Name:  "terraform-plan-json",
+						Usage: "path to the output of terraform show -json. It implies --terraform",
+					},
+					&cli.StringFlag{
+						Name:  "dir",
+						Usage: "working directory of the command",
+					},
+					&cli.StringFlag{
+						Name:  "stdin-file",
+						Usage: "file which is passed to the command's standard input. A relative path is resolved from --dir",
+					},
+					&cli.BoolFlag{
+						Name:  "pty",
+						Usage: "run the command under a pseudo-terminal for commands which change their output when the output isn't a terminal. The standard error output is merged into the standard output. The standard input is passed only if it is a terminal or --stdin-file is set. Only Linux is supported",
+					},
+					&cli.StringSliceFlag{
+						Name:  "env",
+						Usage: "environment variable which is added to the command. The format is '<name>=<value>'",
+					},
+					&cli.IntFlag{
+						Name:  "retry-attempts",
+						Usage: "the maximum number of attempts including the first run. The failed command is rerun",
+					},
+					&cli.DurationFlag{
+						Name:  "retry-delay",
+						Usage: "duration between attempts",
+					},
+					&cli.BoolFlag{
+						Name:  "propagate-exit-code",
+						Usage: "exit with the command's exit code. If it is false, gitlab-comment exits with 0 even if the command fails",
+						Value: true,
+					},
+					&cli.IntFlag{
+						Name:    "comment-error-exit-code",
+						Usage:   "exit code when the command succeeds but posting a comment fails. 0 means the error is ignored",
+						EnvVars: []string{"GITLAB_COMMENT_COMMENT_ERROR_EXIT_CODE"},
+					},
 				},
 			},
 			{
 				Name:   "init",
 				Usage:  "scaffold a configuration file if it doesn't exist",
 				Action: runner.initAction,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "preset",
+						Usage: "comma separated presets (minimal, lint, terraform, test). If it isn't set and the standard input is a terminal, presets are asked interactively",
+					},
+					&cli.StringFlag{
+						Name:    "output",
+						Aliases: []string{"o"},
+						Usage:   "output file path",
+						Value:   "gitlab-comment.yaml",
+					},
+					&cli.BoolFlag{
+						Name:    "force",
+						Aliases: []string{"f"},
+						Usage:   "overwrite the configuration file if it exists",
+					},
+				},
+			},
+			{
+				Name:  "config",
+				Usage: "inspect and migrate configuration files",
+				Subcommands: []*cli.Command{
+					{
+						Name:   "which",
+						Usage:  "show configuration files which are considered and which of them are used",
+						Action: runner.configWhichAction,
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:    "config",
+								Usage:   "configuration file path",
+								EnvVars: []string{"GITLAB_COMMENT_CONFIG"},
+							},
+						},
+					},
+					{
+						Name:   "migrate",
+						Usage:  "convert github-comment's configuration file to gitlab-comment's one",
+						Action: runner.configMigrateAction,
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "input",
+								Usage: "github-comment's configuration file path. By default, the file is searched from the current directory",
+							},
+							&cli.StringFlag{
+								Name:    "output",
+								Aliases: []string{"o"},
+								Usage:   "output file path. By default, the configuration is written to the standard output",
+							},
+							&cli.BoolFlag{
+								Name:    "force",
+								Aliases: []string{"f"},
+								Usage:   "overwrite the output file if it exists",
+							},
+						},
+					},
+				},
 			},
 			{
 				Name:   "hide",
@@ -198,8 +349,9 @@ func (runner *Runner) Run(ctx context.Context, args []string) error { //nolint:f
 						EnvVars: []string{"GITLAB_TOKEN", "GITLAB_ACCESS_TOKEN"},
 					},
 					&cli.StringFlag{
-						Name:  "config",
-						Usage: "configuration file path",
+						Name:    "config",
+						Usage:   "configuration file path",
+						EnvVars: []string{"GITLAB_COMMENT_CONFIG"},
 					},
 					&cli.StringFlag{
 						Name:  "condition",
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v2"
+	"github.com/yuyaban/gitlab-comment/pkg/api"
+	"github.com/yuyaban/gitlab-comment/pkg/config"
+	"github.com/yuyaban/gitlab-comment/pkg/fsys"
+	"github.com/yuyaban/gitlab-comment/pkg/option"
+)
+
+func (runner *Runner) newConfigController() (*api.ConfigController, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("get a current directory path: %w", err)
+	}
+	return &api.ConfigController{
+		Wd:     wd,
+		Stdout: runner.Stdout,
+		Stderr: runner.Stderr,
+		Fsys:   &fsys.Fsys{},
+		Discoverer: &config.Reader{
+			ExistFile: existFile,
+			Getenv:    os.Getenv,
+		},
+	}, nil
+}
+
+// configWhichAction is an entrypoint of the subcommand "config which".
+func (runner *Runner) configWhichAction(c *cli.Context) error {
+	ctrl, err := runner.newConfigController()
+	if err != nil {
+		return err
+	}
+	return ctrl.Which(&option.ConfigWhichOptions{ //nolint:wrapcheck
+		ConfigPath: c.String("config"),
+	})
+}
+
+// configMigrateAction is an entrypoint of the subcommand "config migrate".
+func (runner *Runner) configMigrateAction(c *cli.Context) error {
+	ctrl, err := runner.newConfigController()
+	if err != nil {
+		return err
+	}
+	return ctrl.Migrate(&option.ConfigMigrateOptions{ //nolint:wrapcheck
+		Input:  c.String("input"),
+		Output: c.String("output"),
+		Force:  c.Bool("force"),
+	})
+}
@@ -1,9 +1,11 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/urfave/cli/v2"
 	"github.com/yuyaban/gitlab-comment/pkg/api"
@@ -30,6 +32,27 @@ func parseExecOptions(opts *option.ExecOptions, c *cli.Context) error {
 	opts.Silent = c.Bool("silent")
 	opts.UpdateCondition = c.String("update-condition")
 	opts.LogLevel = c.String("log-level")
+	opts.Timeout = c.Duration("timeout")
+	opts.GracePeriod = c.Duration("grace-period")
+	opts.Shell = c.String("shell")
+	opts.Batch = c.StringSlice("batch")
+	opts.Parallelism = c.Int("parallelism")
+	opts.BatchFail = c.String("batch-fail")
+	opts.MaxOutputSize = c.Int("max-output-size")
+	opts.Live = c.Bool("live")
+	opts.LiveInterval = c.Duration("live-interval")
+	opts.JUnit = c.StringSlice("junit")
+	opts.SARIF = c.StringSlice("sarif")
+	opts.Checkstyle = c.StringSlice("checkstyle")
+	opts.Terraform = c.Bool("terraform")
+	opts.TerraformPlanJSON = c.String("terraform-plan-json")
+	opts.Dir = c.String("dir")
+	opts.StdinFile = c.String("stdin-file")
+	opts.PTY = c.Bool("pty")
+	opts.RetryAttempts = c.Int("retry-attempts")
+	opts.RetryDelay = c.Duration("retry-delay")
+	opts.DontPropagateExitCode = !c.Bool("propagate-exit-code")
+	opts.CommentErrorExitCode = c.Int("comment-error-exit-code")
 
 	vars, err := parseVarsFlag(c.StringSlice("var"))
 	if err != nil {
@@ -44,9 +67,27 @@ func parseExecOptions(opts *option.ExecOptions, c *cli.Context) error {
 	}
 	opts.Vars = vars
 
+	env, err := parseEnvFlag(c.StringSlice("env"))
+	if err != nil {
+		return err
+	}
+	opts.Env = env
+
 	return nil
 }
 
+func parseEnvFlag(envs []string) (map[string]string, error) {
+	m := make(map[string]string, len(envs))
+	for _, e := range envs {
+		a := strings.SplitN(e, "=", 2) //nolint:gomnd
+		if len(a) < 2 {                //nolint:gomnd
+			return nil, errors.New("invalid env flag. The format should be '--env <name>=<value>'")
+		}
+		m[a[0]] = a[1]
+	}
+	return m, nil
+}
+
 func existFile(p string) bool {
 	_, err := os.Stat(p)
 	return err == nil
@@ -70,17 +111,14 @@ func (runner *Runner) execAction(c *cli.Context) error {
 		return fmt.Errorf("get a current directory path: %w", err)
 	}
 
-	cfgReader := config.Reader{
-		ExistFile: existFile,
-	}
-	cfg, err := cfgReader.FindAndRead(opts.ConfigPath, wd)
+	pt := platform.Get()
+
+	cfg, err := readConfig(opts.ConfigPath, wd, pt)
 	if err != nil {
-		return fmt.Errorf("find and read a configuration file: %w", err)
+		return err
 	}
-	opts.SkipNoToken = opts.SkipNoToken || cfg.SkipNoToken
-	opts.Silent = opts.Silent || cfg.Silent
-
-	var pt api.Platform = platform.Get()
+	opts.SkipNoToken = opts.SkipNoToken || cfg.IsSkipNoToken()
+	opts.Silent = opts.Silent || cfg.IsSilent()
 
 	gl, err := getGitLab(&opts.Options, cfg)
 	if err != nil {
@@ -88,19 +126,20 @@ func (runner *Runner) execAction(c *cli.Context) error {
 	}
 
 	ctrl := api.ExecController{
-		Wd:     wd,
-		Getenv: os.Getenv,
-		Stdin:  runner.Stdin,
-		Stdout: runner.Stdout,
-		Stderr: runner.Stderr,
-		GitLab: gl,
+		Wd:      wd,
+		Getenv:  os.Getenv,
+		Environ: os.Environ,
+		Stdin:   runner.Stdin,
+		Stdout:  runner.Stdout,
+		Stderr:  runner.Stderr,
+		GitLab:  gl,
 		Renderer: &template.Renderer{
 			Getenv: os.Getenv,
 		},
 		Executor: &execute.Executor{
 			Stdout: runner.Stdout,
 			Stderr: runner.Stderr,
-			Env:    os.Environ(),
+			Env:    filterEnv(os.Environ(), cfg.Env),
 		},
 		Expr:     &expr.Expr{},
 		Platform: pt,
@@ -108,3 +147,11 @@ func (runner *Runner) execAction(c *cli.Context) error {
 	}
 	return ctrl.Exec(c.Context, opts) //nolint:wrapcheck
 }
+
+// filterEnv filters environment variables which are passed to the command.
+func filterEnv(environ []string, filter *config.EnvFilter) []string {
+	if filter == nil {
+		return environ
+	}
+	return execute.FilterEnv(environ, filter.Allow, filter.Deny)
+}
@@ -4,12 +4,23 @@ import (
 	"github.com/urfave/cli/v2"
 	"github.com/yuyaban/gitlab-comment/pkg/api"
 	"github.com/yuyaban/gitlab-comment/pkg/fsys"
+	"github.com/yuyaban/gitlab-comment/pkg/option"
+	"golang.org/x/term"
 )
 
 // initAction is an entrypoint of the subcommand "init".
 func (runner *Runner) initAction(c *cli.Context) error {
 	ctrl := api.InitController{
-		Fsys: &fsys.Fsys{},
+		Fsys:   &fsys.Fsys{},
+		Stdin:  runner.Stdin,
+		Stderr: runner.Stderr,
+		IsInteractive: func() bool {
+			return term.IsTerminal(0)
+		},
 	}
-	return ctrl.Run(c.Context) //nolint:wrapcheck
+	return ctrl.Run(c.Context, &option.InitOptions{ //nolint:wrapcheck
+		Preset: c.String("preset"),
+		Output: c.String("output"),
+		Force:  c.Bool("force"),
+	})
 }
@@ -99,6 +99,22 @@ func getGitLab(opts *option.Options, cfg *config.Config) (api.GitLab, error) {
 	})
 }
 
+// readConfig finds and reads a configuration file and applies configuration overrides.
+func readConfig(cfgPath, wd string, pt *platform.Platform) (*config.Config, error) {
+	cfgReader := config.Reader{
+		ExistFile: existFile,
+		Getenv:    os.Getenv,
+	}
+	cfg, err := cfgReader.FindAndRead(cfgPath, wd)
+	if err != nil {
+		return nil, fmt.Errorf("find and read a configuration file: %w", err)
+	}
+	if err := cfg.ApplyOverrides(&expr.Expr{}, pt.CIContext()); err != nil {
+		return nil, fmt.Errorf("apply configuration overrides: %w", err)
+	}
+	return cfg, nil
+}
+
 func setLogLevel(logLevel string) {
 	if logLevel == "" {
 		return
@@ -134,17 +150,13 @@ func (runner *Runner) postAction(c *cli.Context) error {
 		return fmt.Errorf("get a current directory path: %w", err)
 	}
 
-	cfgReader := config.Reader{
-		ExistFile: existFile,
-	}
+	pt := platform.Get()
 
-	cfg, err := cfgReader.FindAndRead(opts.ConfigPath, wd)
+	cfg, err := readConfig(opts.ConfigPath, wd, pt)
 	if err != nil {
-		return fmt.Errorf("find and read a configuration file: %w", err)
+		return err
 	}
-	opts.SkipNoToken = opts.SkipNoToken || cfg.SkipNoToken
-
-	var pt api.Platform = platform.Get()
+	opts.SkipNoToken = opts.SkipNoToken || cfg.IsSkipNoToken()
 
 	gl, err := getGitLab(&opts.Options, cfg)
 	if err != nil {
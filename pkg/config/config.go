@@ -3,8 +3,9 @@ package config
 import (
 	"fmt"
 	"os"
-	"path/filepath"
+	"time"
 
+	"github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v2"
 )
 
@@ -16,8 +17,26 @@ type Config struct {
 	Post          map[string]*PostConfig
 	Exec          map[string][]*ExecConfig
 	Hide          map[string]string
-	SkipNoToken   bool `yaml:"skip_no_token"`
-	Silent        bool
+	// SkipNoToken and Silent are pointers so that overrides and upper layers can turn them off
+	SkipNoToken *bool `yaml:"skip_no_token"`
+	Silent      *bool
+	ExpandEnv   *ExpandEnv `yaml:"expand_env"`
+	// Env filters environment variables which are passed to the command of exec
+	Env *EnvFilter
+	// Mask redacts secrets in the output of the command before it's rendered in comments
+	Mask *Mask
+	// Overrides are partial configurations which are merged when the condition matches.
+	Overrides []*Override
+}
+
+// IsSkipNoToken returns true if skip_no_token is enabled.
+func (cfg *Config) IsSkipNoToken() bool {
+	return cfg.SkipNoToken != nil && *cfg.SkipNoToken
+}
+
+// IsSilent returns true if silent is enabled.
+func (cfg *Config) IsSilent() bool {
+	return cfg.Silent != nil && *cfg.Silent
 }
 
 type Base struct {
@@ -25,6 +44,29 @@ type Base struct {
 	Repo string
 }
 
+// EnvFilter filters environment variables by glob patterns of their names such as "AWS_*".
+// If Allow is empty, all environment variables are allowed.
+// Deny takes precedence over Allow.
+type EnvFilter struct {
+	Allow []string
+	Deny  []string
+}
+
+// Mask is settings about masking secrets.
+// Values of CI_*_TOKEN and some other well-known secrets are always masked.
+// If max_output_size cuts a line in the middle of a secret, the kept part of the secret is also masked.
+type Mask struct {
+	// Env are glob patterns of environment variable names whose values are masked
+	Env []string
+	// Patterns are regular expressions of secrets
+	Patterns []string
+	// GitLabMaskedVariables enables to get masked CI/CD variables of the project by GitLab API and mask their values.
+	// The access token must belong to a user who has at least the Maintainer role of the project,
+	// because CI_JOB_TOKEN can't access the project variables API.
+	// If the variables can't be got, a warning is output and only other secrets are masked
+	GitLabMaskedVariables bool `yaml:"gitlab_masked_variables"`
+}
+
 type PostConfig struct {
 	Template           string
 	TemplateForTooLong string
@@ -93,33 +135,146 @@ type ExecConfig struct {
 	DontComment        bool     `yaml:"dont_comment"`
 	EmbeddedVarNames   []string `yaml:"embedded_var_names"`
 	UpdateCondition    string   `yaml:"update"`
+	// EmbeddedResultNames are names of results such as "Duration" and "Tests" which are embedded in the comment's metadata.
+	// They are exposed as .Previous in the next run
+	EmbeddedResultNames []string `yaml:"embedded_result_names"`
+	// Continue makes the following ExecConfigs tested even if this ExecConfig matches.
+	// Then comments of all matched ExecConfigs are posted
+	Continue bool
+	// Timeout is the timeout of the command.
+	// The command is run before an ExecConfig is selected by When,
+	// so settings about how the command runs are collected from all ExecConfigs of the template key.
+	// The longest Timeout among ExecConfigs of the template key is used
+	Timeout time.Duration
+	// Shell is a shell script which is run instead of the command arguments.
+	// If multiple ExecConfigs of the template key have Shell, they must be the same, otherwise gitlab-comment fails
+	Shell string
+	// Steps are commands which are run sequentially.
+	// If multiple ExecConfigs of the template key have Steps, they must be the same, otherwise gitlab-comment fails
+	Steps []*ExecStep
+	// Batch are commands which are run concurrently.
+	// If multiple ExecConfigs of the template key have Batch, they must be the same, otherwise gitlab-comment fails
+	Batch *ExecBatch
+	// MaxOutputSize is the maximum size of each captured output in bytes.
+	// If multiple ExecConfigs of the template key have MaxOutputSize, they must be the same, otherwise gitlab-comment fails
+	MaxOutputSize int `yaml:"max_output_size"`
+	// JUnit are glob patterns of JUnit XML reports which are parsed after the command exits.
+	// Patterns of all ExecConfigs of the template key are used
+	JUnit []string `yaml:"junit"`
+	// SARIF and Checkstyle are glob patterns of linters' reports which are parsed after the command exits.
+	// Patterns of all ExecConfigs of the template key are used
+	SARIF      []string `yaml:"sarif"`
+	Checkstyle []string `yaml:"checkstyle"`
+	// Terraform enables to parse the result of terraform plan.
+	// If multiple ExecConfigs of the template key have Terraform, they must be the same, otherwise gitlab-comment fails
+	Terraform *ExecTerraform
+	// Dir is the working directory of the command. A relative path is resolved from the current directory.
+	// If multiple ExecConfigs of the template key have Dir, they must be the same, otherwise gitlab-comment fails
+	Dir string
+	// StdinFile is the file which is passed to the command's standard input. A relative path is resolved from Dir.
+	// If multiple ExecConfigs of the template key have StdinFile, they must be the same, otherwise gitlab-comment fails
+	StdinFile string `yaml:"stdin_file"`
+	// Env are environment variables which are added to the command.
+	// Variables of all ExecConfigs of the template key are added. The same variable must have the same value, otherwise gitlab-comment fails
+	Env map[string]string
+	// Retry is the policy to rerun the failed command.
+	// If multiple ExecConfigs of the template key have Retry, they must be the same, otherwise gitlab-comment fails
+	Retry *ExecRetry
+	// ExitCodeMap converts the command's exit code to gitlab-comment's exit code.
+	// If multiple ExecConfigs of the template key have ExitCodeMap, they must be the same, otherwise gitlab-comment fails
+	ExitCodeMap map[int]int `yaml:"exit_code_map"`
+	// FailOn is a condition whether gitlab-comment fails. If it isn't set, gitlab-comment fails if the exit code isn't 0.
+	// If multiple ExecConfigs of the template key have FailOn, they must be the same, otherwise gitlab-comment fails
+	FailOn string `yaml:"fail_on"`
+	// CaptureFiles are files which are read after the command exits and exposed as .Files.<name>.
+	// CaptureFiles of all ExecConfigs of the template key are used. Files which have the same name must have the same settings, otherwise gitlab-comment fails
+	CaptureFiles []*ExecCaptureFile `yaml:"capture_files"`
 }
 
-type ExistFile func(string) bool
+type ExecCaptureFile struct {
+	Name string
+	// Path is a file path or a glob pattern. A relative path is resolved from the command's working directory.
+	// If multiple files match, their contents are concatenated
+	Path string
+	// MaxSize is the maximum size of the content in bytes. The rest is omitted
+	MaxSize int `yaml:"max_size"`
+	// Missing is the policy when no file is found. "ignore" (default), "warn", or "fail"
+	Missing string
+}
 
-type Reader struct {
-	ExistFile ExistFile
+// ExecRetry is the policy to rerun the failed command.
+// Every attempt reads the standard input from the beginning only if it's a file such as stdin_file.
+// Other inputs such as pipes are passed as is, so later attempts read only what earlier attempts left.
+type ExecRetry struct {
+	// Attempts is the maximum number of attempts including the first run
+	Attempts int
+	// Delay is the duration between attempts
+	Delay time.Duration
+	// When is a condition whether the failed command is retried. The attempt's result is passed.
+	// If it is empty, the command is retried whenever it fails
+	When string
 }
 
-func (reader *Reader) find(wd string) (string, bool) {
-	names := []string{
-		"gitlab-comment.yml", "gitlab-comment.yaml",
-		".gitlab-comment.yml", ".gitlab-comment.yaml",
-		"github-comment.yaml", "github-comment.yml",
-		".github-comment.yml", ".github-comment.yaml",
+type ExecTerraform struct {
+	Enabled bool
+	// PlanJSON is the path to the output of terraform show -json.
+	// If it is empty, the output of the command is parsed.
+	// Then the output isn't cut unless max_output_size is set, because the plan can't be parsed from the cut output
+	PlanJSON string `yaml:"plan_json"`
+}
+
+// UnmarshalYAML accepts either a bool or a map.
+// terraform: true enables to parse the output of the command.
+func (tf *ExecTerraform) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var val interface{}
+	if err := unmarshal(&val); err != nil {
+		return err
 	}
-	for {
-		for _, name := range names {
-			p := filepath.Join(wd, name)
-			if reader.ExistFile(p) {
-				return p, true
-			}
-		}
-		if wd == "/" || wd == "" {
-			return "", false
+	if b, ok := val.(bool); ok {
+		tf.Enabled = b
+		return nil
+	}
+	m, ok := val.(map[interface{}]interface{})
+	if !ok {
+		return fmt.Errorf("invalid config. terraform should be bool or map[string]interface{}: %+v", val)
+	}
+	tf.Enabled = true
+	if p, ok := m["plan_json"]; ok {
+		s, ok := p.(string)
+		if !ok {
+			return fmt.Errorf("invalid config. terraform.plan_json should be string: %+v", p)
 		}
-		wd = filepath.Dir(wd)
+		tf.PlanJSON = s
 	}
+	return nil
+}
+
+type ExecBatch struct {
+	// Parallelism is the maximum number of commands which run at the same time.
+	// If it is zero, all commands run at the same time
+	Parallelism int
+	// Fail is the policy of the exit code. "any" (default) or "all".
+	// If it is "any", the batch fails if any command fails.
+	// If it is "all", the batch fails only if all commands fail
+	Fail     string
+	Commands []*ExecStep
+}
+
+type ExecStep struct {
+	Name string
+	// Run is a shell script
+	Run string
+	// Dir is the working directory of the step. A relative path is resolved from ExecConfig's Dir
+	Dir string
+}
+
+type ExistFile func(string) bool
+
+type Reader struct {
+	ExistFile ExistFile
+	// Getenv returns the environment variable. os.Getenv
+	// It is used to expand environment variables in the configuration file.
+	Getenv func(string) string
 }
 
 func (reader *Reader) read(p string) (*Config, error) {
@@ -132,37 +287,62 @@ func (reader *Reader) read(p string) (*Config, error) {
 	if err := yaml.NewDecoder(f).Decode(cfg); err != nil {
 		return nil, fmt.Errorf("decode a configuration file as YAML: %w", err)
 	}
+	if err := expandEnv(cfg, reader.Getenv); err != nil {
+		return nil, fmt.Errorf("expand environment variables in a configuration file: %w", err)
+	}
 	return cfg, nil
 }
 
 const defaultHideCondition = "Comment.HasMeta && Comment.Meta.SHA1 != Commit.SHA1"
 
+// FindAndRead reads the system-level configuration file, the user-level configuration file,
+// and the repository's configuration file, and merges them.
+// The user-level configuration overrides the system-level one, and the repository's configuration overrides both.
+// If cfgPath is given, it is used instead of searching the repository's configuration file.
 func (reader *Reader) FindAndRead(cfgPath, wd string) (*Config, error) {
-	cfg := &Config{
-		Hide: map[string]string{
-			"default": defaultHideCondition,
-		},
-	}
-	if cfgPath == "" {
-		p, b := reader.find(wd)
-		if !b {
-			return cfg, nil
-		}
-		cfgPath = p
-	}
-	cfg, err := reader.read(cfgPath) //nolint:ifshort
+	cfg, err := reader.readLayers(reader.Discover(cfgPath, wd))
 	if err != nil {
 		return nil, err
 	}
 	if cfg.Hide == nil {
-		cfg.Hide = map[string]string{
-			"default": defaultHideCondition,
+		cfg.Hide = map[string]string{}
+	}
+	if _, ok := cfg.Hide["default"]; !ok {
+		cfg.Hide["default"] = defaultHideCondition
+	}
+	return cfg, nil
+}
+
+// readLayers reads used candidates and merges them in order, so later candidates take precedence.
+// Scalar values are overwritten, maps such as vars, templates, post, exec and hide are merged by key,
+// and lists such as entries of an exec template key are replaced as a whole.
+func (reader *Reader) readLayers(candidates []*Candidate) (*Config, error) {
+	cfg := &Config{}
+	legacyPath := ""
+	repoConfigFound := false
+	for _, candidate := range candidates {
+		if candidate.Legacy {
+			if legacyPath == "" {
+				legacyPath = candidate.Path
+			}
+			continue
+		}
+		if !candidate.Used {
+			continue
+		}
+		c, err := reader.read(candidate.Path)
+		if err != nil {
+			return nil, err
+		}
+		cfg.merge(c)
+		if candidate.Layer == LayerRepository || candidate.Layer == LayerExplicit {
+			repoConfigFound = true
 		}
-		return cfg, nil
 	}
-	if _, ok := cfg.Hide["default"]; ok {
-		return cfg, nil
+	if !repoConfigFound && legacyPath != "" {
+		logrus.WithFields(logrus.Fields{
+			"path": legacyPath,
+		}).Warn("github-comment's configuration file is ignored. Run 'gitlab-comment config migrate' to convert it")
 	}
-	cfg.Hide["default"] = defaultHideCondition
 	return cfg, nil
 }
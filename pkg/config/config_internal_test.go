@@ -0,0 +1,167 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReader_readLayers(t *testing.T) { //nolint:funlen
+	t.Parallel()
+	data := []struct {
+		title  string
+		layers map[string]string
+		exp    *Config
+	}{
+		{
+			title: "scalar",
+			layers: map[string]string{
+				LayerSystem: `gitlab_base_url: https://system.example.com
+base:
+  org: system
+  repo: system
+`,
+				LayerUser: `gitlab_base_url: https://user.example.com
+base:
+  org: user
+`,
+				LayerRepository: `base:
+  org: repo
+`,
+			},
+			exp: &Config{
+				GitLabBaseURL: "https://user.example.com",
+				Base: &Base{
+					Org:  "repo",
+					Repo: "system",
+				},
+			},
+		},
+		{
+			title: "map",
+			layers: map[string]string{
+				LayerSystem: `vars:
+  team: system
+  nested:
+    a: system
+    b: system
+templates:
+  header: system
+  footer: system
+`,
+				LayerUser: `vars:
+  nested:
+    b: user
+templates:
+  footer: user
+`,
+				LayerRepository: `vars:
+  team: repo
+templates:
+  header: repo
+`,
+			},
+			exp: &Config{
+				Vars: map[string]interface{}{
+					"team": "repo",
+					"nested": map[interface{}]interface{}{
+						"a": "system",
+						"b": "user",
+					},
+				},
+				Templates: map[string]string{
+					"header": "repo",
+					"footer": "user",
+				},
+			},
+		},
+		{
+			title: "list",
+			layers: map[string]string{
+				LayerSystem: `exec:
+  default:
+    - when: ExitCode != 0
+      template: system failure
+    - when: "true"
+      template: system success
+  lint:
+    - when: "true"
+      template: system lint
+`,
+				LayerRepository: `exec:
+  default:
+    - when: "true"
+      template: repo
+`,
+			},
+			exp: &Config{
+				Exec: map[string][]*ExecConfig{
+					"default": {
+						{When: "true", Template: "repo"},
+					},
+					"lint": {
+						{When: "true", Template: "system lint"},
+					},
+				},
+			},
+		},
+		{
+			title: "top-level env and env of exec entries",
+			layers: map[string]string{
+				LayerSystem: `env:
+  allow: ["CI_*"]
+  deny: ["AWS_*"]
+`,
+				LayerRepository: `env:
+  deny: ["GOOGLE_*"]
+exec:
+  default:
+    - when: "true"
+      env:
+        FOO: bar
+`,
+			},
+			exp: &Config{
+				Env: &EnvFilter{
+					Allow: []string{"CI_*"},
+					Deny:  []string{"AWS_*", "GOOGLE_*"},
+				},
+				Exec: map[string][]*ExecConfig{
+					"default": {
+						{When: "true", Env: map[string]string{"FOO": "bar"}},
+					},
+				},
+			},
+		},
+	}
+	for _, d := range data {
+		d := d
+		t.Run(d.title, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			var candidates []*Candidate
+			for _, layer := range []string{LayerSystem, LayerUser, LayerRepository} {
+				content, ok := d.layers[layer]
+				if !ok {
+					continue
+				}
+				p := filepath.Join(dir, layer+".yaml")
+				if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+					t.Fatal(err)
+				}
+				candidates = append(candidates, &Candidate{
+					Path:   p,
+					Layer:  layer,
+					Exists: true,
+					Used:   true,
+				})
+			}
+			reader := &Reader{}
+			cfg, err := reader.readLayers(candidates)
+			require.Nil(t, err)
+			require.Equal(t, d.exp, cfg)
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"path/filepath"
+)
+
+const systemConfigPath = "/etc/gitlab-comment/config.yaml"
+
+const (
+	LayerSystem     = "system"
+	LayerUser       = "user"
+	LayerRepository = "repository"
+	LayerExplicit   = "explicit"
+)
+
+// Candidate is a configuration file path which is considered in the configuration discovery.
+type Candidate struct {
+	Path  string
+	Layer string
+	// Exists is true if the file exists
+	Exists bool
+	// Used is true if the file is read
+	Used bool
+	// Legacy is true if the file is github-comment's configuration file.
+	// Legacy files are never used.
+	Legacy bool
+	// Reason describes why the file is used or not
+	Reason string
+}
+
+func configNames() []string {
+	return []string{
+		"gitlab-comment.yml", "gitlab-comment.yaml",
+		".gitlab-comment.yml", ".gitlab-comment.yaml",
+	}
+}
+
+// LegacyConfigNames returns file names of github-comment's configuration files.
+func LegacyConfigNames() []string {
+	return []string{
+		"github-comment.yaml", "github-comment.yml",
+		".github-comment.yml", ".github-comment.yaml",
+	}
+}
+
+// userConfigPath returns the path to the user-level configuration file.
+// If XDG_CONFIG_HOME isn't set, $HOME/.config is used.
+func (reader *Reader) userConfigPath() string {
+	if reader.Getenv == nil {
+		return ""
+	}
+	if dir := reader.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "gitlab-comment", "config.yaml")
+	}
+	if home := reader.Getenv("HOME"); home != "" {
+		return filepath.Join(home, ".config", "gitlab-comment", "config.yaml")
+	}
+	return ""
+}
+
+func (reader *Reader) globalCandidate(p, layer string) *Candidate {
+	candidate := &Candidate{
+		Path:  p,
+		Layer: layer,
+	}
+	if !reader.ExistFile(p) {
+		candidate.Reason = "not found"
+		return candidate
+	}
+	candidate.Exists = true
+	candidate.Used = true
+	candidate.Reason = "found"
+	return candidate
+}
+
+// find searches the repository's configuration file from wd to the root directory.
+// Every path which is checked is returned as a candidate.
+// github-comment's configuration files are returned only if they exist.
+func (reader *Reader) find(wd string) []*Candidate {
+	var candidates []*Candidate
+	for {
+		for _, name := range configNames() {
+			p := filepath.Join(wd, name)
+			if reader.ExistFile(p) {
+				return append(candidates, &Candidate{
+					Path:   p,
+					Layer:  LayerRepository,
+					Exists: true,
+					Used:   true,
+					Reason: "found",
+				})
+			}
+			candidates = append(candidates, &Candidate{
+				Path:   p,
+				Layer:  LayerRepository,
+				Reason: "not found",
+			})
+		}
+		for _, name := range LegacyConfigNames() {
+			p := filepath.Join(wd, name)
+			if reader.ExistFile(p) {
+				candidates = append(candidates, &Candidate{
+					Path:   p,
+					Layer:  LayerRepository,
+					Exists: true,
+					Legacy: true,
+					Reason: "ignored because this is github-comment's configuration file. Run 'gitlab-comment config migrate' to convert it",
+				})
+			}
+		}
+		if wd == "/" || wd == "" {
+			return candidates
+		}
+		wd = filepath.Dir(wd)
+	}
+}
+
+// Discover returns candidates of configuration files in ascending order of precedence.
+// If cfgPath is given, the repository's configuration file isn't searched.
+func (reader *Reader) Discover(cfgPath, wd string) []*Candidate {
+	candidates := []*Candidate{
+		reader.globalCandidate(systemConfigPath, LayerSystem),
+	}
+	if p := reader.userConfigPath(); p != "" {
+		candidates = append(candidates, reader.globalCandidate(p, LayerUser))
+	}
+	if cfgPath != "" {
+		candidate := &Candidate{
+			Path:   cfgPath,
+			Layer:  LayerExplicit,
+			Exists: reader.ExistFile(cfgPath),
+			Used:   true,
+			Reason: "specified by --config or GITLAB_COMMENT_CONFIG",
+		}
+		if !candidate.Exists {
+			candidate.Reason += " but not found"
+		}
+		return append(candidates, candidate)
+	}
+	return append(candidates, reader.find(wd)...)
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReader_Discover(t *testing.T) { //nolint:funlen
+	t.Parallel()
+	data := []struct {
+		title   string
+		files   []string
+		env     map[string]string
+		cfgPath string
+		wd      string
+		exp     []*Candidate
+	}{
+		{
+			title: "repository's configuration file in the parent directory",
+			files: []string{
+				"/etc/gitlab-comment/config.yaml",
+				"/home/foo/.config/gitlab-comment/config.yaml",
+				"/repo/.gitlab-comment.yaml",
+			},
+			env: map[string]string{
+				"HOME": "/home/foo",
+			},
+			wd: "/repo/sub",
+			exp: []*Candidate{
+				{Path: "/etc/gitlab-comment/config.yaml", Layer: LayerSystem, Exists: true, Used: true, Reason: "found"},
+				{Path: "/home/foo/.config/gitlab-comment/config.yaml", Layer: LayerUser, Exists: true, Used: true, Reason: "found"},
+				{Path: "/repo/sub/gitlab-comment.yml", Layer: LayerRepository, Reason: "not found"},
+				{Path: "/repo/sub/gitlab-comment.yaml", Layer: LayerRepository, Reason: "not found"},
+				{Path: "/repo/sub/.gitlab-comment.yml", Layer: LayerRepository, Reason: "not found"},
+				{Path: "/repo/sub/.gitlab-comment.yaml", Layer: LayerRepository, Reason: "not found"},
+				{Path: "/repo/gitlab-comment.yml", Layer: LayerRepository, Reason: "not found"},
+				{Path: "/repo/gitlab-comment.yaml", Layer: LayerRepository, Reason: "not found"},
+				{Path: "/repo/.gitlab-comment.yml", Layer: LayerRepository, Reason: "not found"},
+				{Path: "/repo/.gitlab-comment.yaml", Layer: LayerRepository, Exists: true, Used: true, Reason: "found"},
+			},
+		},
+		{
+			title: "XDG_CONFIG_HOME and github-comment's configuration file",
+			files: []string{
+				"/github-comment.yaml",
+			},
+			env: map[string]string{
+				"HOME":            "/home/foo",
+				"XDG_CONFIG_HOME": "/xdg",
+			},
+			wd: "/",
+			exp: []*Candidate{
+				{Path: "/etc/gitlab-comment/config.yaml", Layer: LayerSystem, Reason: "not found"},
+				{Path: "/xdg/gitlab-comment/config.yaml", Layer: LayerUser, Reason: "not found"},
+				{Path: "/gitlab-comment.yml", Layer: LayerRepository, Reason: "not found"},
+				{Path: "/gitlab-comment.yaml", Layer: LayerRepository, Reason: "not found"},
+				{Path: "/.gitlab-comment.yml", Layer: LayerRepository, Reason: "not found"},
+				{Path: "/.gitlab-comment.yaml", Layer: LayerRepository, Reason: "not found"},
+				{
+					Path: "/github-comment.yaml", Layer: LayerRepository, Exists: true, Legacy: true,
+					Reason: "ignored because this is github-comment's configuration file. Run 'gitlab-comment config migrate' to convert it",
+				},
+			},
+		},
+		{
+			title:   "explicit configuration file",
+			files:   []string{"/repo/.gitlab-comment.yaml"},
+			cfgPath: "/config.yaml",
+			wd:      "/repo",
+			exp: []*Candidate{
+				{Path: "/etc/gitlab-comment/config.yaml", Layer: LayerSystem, Reason: "not found"},
+				{Path: "/config.yaml", Layer: LayerExplicit, Used: true, Reason: "specified by --config or GITLAB_COMMENT_CONFIG but not found"},
+			},
+		},
+	}
+	for _, d := range data {
+		d := d
+		t.Run(d.title, func(t *testing.T) {
+			t.Parallel()
+			files := make(map[string]struct{}, len(d.files))
+			for _, file := range d.files {
+				files[file] = struct{}{}
+			}
+			reader := &Reader{
+				ExistFile: func(p string) bool {
+					_, ok := files[p]
+					return ok
+				},
+				Getenv: func(k string) string {
+					return d.env[k]
+				},
+			}
+			require.Equal(t, d.exp, reader.Discover(d.cfgPath, d.wd))
+		})
+	}
+}
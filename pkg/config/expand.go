@@ -0,0 +1,134 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ExpandEnv is the setting of the environment variable expansion in the configuration file.
+// Only environment variables listed in Allow or starting with one of Prefixes are expanded,
+// so that arbitrary environment variables aren't leaked into comments.
+type ExpandEnv struct {
+	Allow    []string
+	Prefixes []string
+}
+
+func (ee *ExpandEnv) allowed(name string) bool {
+	for _, a := range ee.Allow {
+		if a == name {
+			return true
+		}
+	}
+	for _, prefix := range ee.Prefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+var envVarPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// expand expands ${VAR} and ${VAR:-default} in s.
+func (ee *ExpandEnv) expand(s string, getenv func(string) string) (string, error) {
+	var expandErr error
+	ret := envVarPattern.ReplaceAllStringFunc(s, func(match string) string {
+		a := envVarPattern.FindStringSubmatch(match)
+		name := a[1]
+		if !ee.allowed(name) {
+			if expandErr == nil {
+				expandErr = errors.New("the environment variable " + name + " isn't allowed to be expanded. Add it to expand_env.allow or expand_env.prefixes")
+			}
+			return match
+		}
+		if v := getenv(name); v != "" {
+			return v
+		}
+		return a[3]
+	})
+	return ret, expandErr
+}
+
+func (ee *ExpandEnv) expandValue(val interface{}, getenv func(string) string) (interface{}, error) {
+	switch v := val.(type) {
+	case string:
+		return ee.expand(v, getenv)
+	case map[interface{}]interface{}:
+		for k, a := range v {
+			b, err := ee.expandValue(a, getenv)
+			if err != nil {
+				return nil, err
+			}
+			v[k] = b
+		}
+		return v, nil
+	case map[string]interface{}:
+		for k, a := range v {
+			b, err := ee.expandValue(a, getenv)
+			if err != nil {
+				return nil, err
+			}
+			v[k] = b
+		}
+		return v, nil
+	case []interface{}:
+		for i, a := range v {
+			b, err := ee.expandValue(a, getenv)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = b
+		}
+		return v, nil
+	default:
+		return val, nil
+	}
+}
+
+// expandEnv expands environment variables in gitlab_base_url, base and vars.
+// Templates aren't expanded because they may contain shell scripts.
+func expandEnv(cfg *Config, getenv func(string) string) error {
+	ee := cfg.ExpandEnv
+	if ee == nil || getenv == nil {
+		return nil
+	}
+	if err := ee.expandConfig(cfg, getenv); err != nil {
+		return err
+	}
+	for i, override := range cfg.Overrides {
+		if err := ee.expandConfig(&override.Config, getenv); err != nil {
+			return fmt.Errorf("overrides[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (ee *ExpandEnv) expandConfig(cfg *Config, getenv func(string) string) error {
+	s, err := ee.expand(cfg.GitLabBaseURL, getenv)
+	if err != nil {
+		return fmt.Errorf("expand gitlab_base_url: %w", err)
+	}
+	cfg.GitLabBaseURL = s
+	if cfg.Base != nil {
+		org, err := ee.expand(cfg.Base.Org, getenv)
+		if err != nil {
+			return fmt.Errorf("expand base.org: %w", err)
+		}
+		cfg.Base.Org = org
+		repo, err := ee.expand(cfg.Base.Repo, getenv)
+		if err != nil {
+			return fmt.Errorf("expand base.repo: %w", err)
+		}
+		cfg.Base.Repo = repo
+	}
+	for k, v := range cfg.Vars {
+		a, err := ee.expandValue(v, getenv)
+		if err != nil {
+			return fmt.Errorf("expand vars.%s: %w", k, err)
+		}
+		cfg.Vars[k] = a
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandEnv_expand(t *testing.T) { //nolint:funlen
+	t.Parallel()
+	env := map[string]string{
+		"CI_SERVER_URL":        "https://gitlab.example.com",
+		"GITLAB_COMMENT_OWNER": "yuyaban",
+		"SECRET_TOKEN":         "xxx",
+	}
+	getenv := func(k string) string {
+		return env[k]
+	}
+	data := []struct {
+		title string
+		ee    *ExpandEnv
+		s     string
+		exp   string
+		isErr bool
+	}{
+		{
+			title: "no variable",
+			ee:    &ExpandEnv{},
+			s:     "foo",
+			exp:   "foo",
+		},
+		{
+			title: "allowed variable",
+			ee: &ExpandEnv{
+				Allow: []string{"CI_SERVER_URL"},
+			},
+			s:   "${CI_SERVER_URL}/api/v4",
+			exp: "https://gitlab.example.com/api/v4",
+		},
+		{
+			title: "prefix",
+			ee: &ExpandEnv{
+				Prefixes: []string{"GITLAB_COMMENT_"},
+			},
+			s:   "${GITLAB_COMMENT_OWNER}",
+			exp: "yuyaban",
+		},
+		{
+			title: "default value",
+			ee: &ExpandEnv{
+				Prefixes: []string{"GITLAB_COMMENT_"},
+			},
+			s:   "${GITLAB_COMMENT_REPO:-gitlab-comment}",
+			exp: "gitlab-comment",
+		},
+		{
+			title: "default value is ignored if the variable is set",
+			ee: &ExpandEnv{
+				Prefixes: []string{"GITLAB_COMMENT_"},
+			},
+			s:   "${GITLAB_COMMENT_OWNER:-foo}",
+			exp: "yuyaban",
+		},
+		{
+			title: "$VAR isn't expanded",
+			ee: &ExpandEnv{
+				Allow: []string{"SECRET_TOKEN"},
+			},
+			s:   "$SECRET_TOKEN",
+			exp: "$SECRET_TOKEN",
+		},
+		{
+			title: "not allowed",
+			ee: &ExpandEnv{
+				Prefixes: []string{"GITLAB_COMMENT_"},
+			},
+			s:     "${SECRET_TOKEN}",
+			isErr: true,
+		},
+	}
+	for _, d := range data {
+		d := d
+		t.Run(d.title, func(t *testing.T) {
+			t.Parallel()
+			s, err := d.ee.expand(d.s, getenv)
+			if d.isErr {
+				require.NotNil(t, err)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, d.exp, s)
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+
+	"gopkg.in/yaml.v2"
+)
+
+var prNumberPattern = regexp.MustCompile(`\bPRNumber\b`)
+
+// unsupportedGitHubCommentKeys are top-level keys of github-comment's configuration which gitlab-comment doesn't support.
+var unsupportedGitHubCommentKeys = map[string]string{ //nolint:gochecknoglobals
+	"ghe_base_url":         "GitHub Enterprise isn't supported. Use gitlab_base_url instead",
+	"ghe_graphql_endpoint": "GitHub Enterprise isn't supported. Use gitlab_base_url instead",
+	"complement":           "complement isn't supported. gitlab-comment gets parameters from GitLab CI's built-in environment variables",
+}
+
+// MigrateGitHubComment converts github-comment's configuration to gitlab-comment's one.
+// PRNumber in templates and expressions is renamed to MRNumber, and unsupported settings are removed.
+// The second returned value is the list of warnings about removed settings.
+func MigrateGitHubComment(b []byte) ([]byte, []string, error) {
+	src := yaml.MapSlice{}
+	if err := yaml.Unmarshal(b, &src); err != nil {
+		return nil, nil, fmt.Errorf("parse github-comment's configuration as YAML: %w", err)
+	}
+	dst := make(yaml.MapSlice, 0, len(src))
+	var warnings []string
+	for _, item := range src {
+		key, ok := item.Key.(string)
+		if !ok {
+			return nil, nil, fmt.Errorf("invalid config. the key should be string: %+v", item.Key)
+		}
+		if msg, ok := unsupportedGitHubCommentKeys[key]; ok {
+			warnings = append(warnings, key+" is removed: "+msg)
+			continue
+		}
+		dst = append(dst, yaml.MapItem{
+			Key:   key,
+			Value: renamePRNumber(item.Value),
+		})
+	}
+	out, err := yaml.Marshal(dst)
+	if err != nil {
+		return nil, nil, fmt.Errorf("encode a configuration as YAML: %w", err)
+	}
+	return out, warnings, nil
+}
+
+// renamePRNumber renames PRNumber to MRNumber in all string values recursively.
+func renamePRNumber(val interface{}) interface{} {
+	switch v := val.(type) {
+	case string:
+		return prNumberPattern.ReplaceAllString(v, "MRNumber")
+	case yaml.MapSlice:
+		for i, item := range v {
+			v[i].Value = renamePRNumber(item.Value)
+		}
+		return v
+	case []interface{}:
+		for i, a := range v {
+			v[i] = renamePRNumber(a)
+		}
+		return v
+	default:
+		return val
+	}
+}
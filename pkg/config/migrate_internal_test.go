@@ -0,0 +1,80 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrateGitHubComment(t *testing.T) { //nolint:funlen
+	t.Parallel()
+	data := []struct {
+		title    string
+		input    string
+		exp      string
+		warnings []string
+		isErr    bool
+	}{
+		{
+			title: "PRNumber is renamed",
+			input: `post:
+  default: "#{{.PRNumber}} {{.Org}}"
+exec:
+  default:
+  - when: PRNumber > 0
+    template: "{{.PRNumbers}}"
+`,
+			exp: `post:
+  default: '#{{.MRNumber}} {{.Org}}'
+exec:
+  default:
+  - when: MRNumber > 0
+    template: '{{.PRNumbers}}'
+`,
+		},
+		{
+			title: "hide is kept",
+			input: `hide:
+  default: Comment.HasMeta && Comment.Meta.SHA1 != Commit.SHA1
+`,
+			exp: `hide:
+  default: Comment.HasMeta && Comment.Meta.SHA1 != Commit.SHA1
+`,
+		},
+		{
+			title: "unsupported settings are removed",
+			input: `ghe_base_url: https://ghe.example.com
+complement:
+  pr: []
+templates:
+  foo: bar
+`,
+			exp: `templates:
+  foo: bar
+`,
+			warnings: []string{
+				"ghe_base_url is removed: GitHub Enterprise isn't supported. Use gitlab_base_url instead",
+				"complement is removed: complement isn't supported. gitlab-comment gets parameters from GitLab CI's built-in environment variables",
+			},
+		},
+		{
+			title: "invalid YAML",
+			input: `foo: [`,
+			isErr: true,
+		},
+	}
+	for _, d := range data {
+		d := d
+		t.Run(d.title, func(t *testing.T) {
+			t.Parallel()
+			out, warnings, err := MigrateGitHubComment([]byte(d.input))
+			if d.isErr {
+				require.NotNil(t, err)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, d.exp, string(out))
+			require.Equal(t, d.warnings, warnings)
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Override is a partial configuration which is merged into the base configuration
+// if the condition If is true.
+type Override struct {
+	If     string
+	Config `yaml:",inline"`
+}
+
+type Matcher interface {
+	Match(expression string, params interface{}) (bool, error)
+}
+
+// ApplyOverrides evaluates overrides with params in order and deep-merges matched ones into cfg.
+// Later overrides take precedence over earlier ones.
+func (cfg *Config) ApplyOverrides(matcher Matcher, params interface{}) error {
+	for i, override := range cfg.Overrides {
+		if override.If == "" {
+			return fmt.Errorf("overrides[%d].if is required", i)
+		}
+		if len(override.Overrides) != 0 {
+			return errors.New("overrides can't be nested")
+		}
+		f, err := matcher.Match(override.If, params)
+		if err != nil {
+			return fmt.Errorf("evaluate overrides[%d].if: %w", i, err)
+		}
+		if !f {
+			continue
+		}
+		cfg.merge(&override.Config)
+	}
+	cfg.Overrides = nil
+	return nil
+}
+
+// merge deep-merges src into cfg.
+// Values of src take precedence, but empty values of src don't clear values of cfg.
+func (cfg *Config) merge(src *Config) { //nolint:cyclop
+	if src.Base != nil {
+		if cfg.Base == nil {
+			cfg.Base = &Base{}
+		}
+		if src.Base.Org != "" {
+			cfg.Base.Org = src.Base.Org
+		}
+		if src.Base.Repo != "" {
+			cfg.Base.Repo = src.Base.Repo
+		}
+	}
+	if src.GitLabBaseURL != "" {
+		cfg.GitLabBaseURL = src.GitLabBaseURL
+	}
+	if src.Vars != nil {
+		if cfg.Vars == nil {
+			cfg.Vars = make(map[string]interface{}, len(src.Vars))
+		}
+		for k, v := range src.Vars {
+			cfg.Vars[k] = mergeValue(cfg.Vars[k], v)
+		}
+	}
+	if src.Templates != nil {
+		if cfg.Templates == nil {
+			cfg.Templates = make(map[string]string, len(src.Templates))
+		}
+		for k, v := range src.Templates {
+			cfg.Templates[k] = v
+		}
+	}
+	if src.Post != nil {
+		if cfg.Post == nil {
+			cfg.Post = make(map[string]*PostConfig, len(src.Post))
+		}
+		for k, v := range src.Post {
+			cfg.Post[k] = v
+		}
+	}
+	if src.Exec != nil {
+		if cfg.Exec == nil {
+			cfg.Exec = make(map[string][]*ExecConfig, len(src.Exec))
+		}
+		for k, v := range src.Exec {
+			cfg.Exec[k] = v
+		}
+	}
+	if src.Hide != nil {
+		if cfg.Hide == nil {
+			cfg.Hide = make(map[string]string, len(src.Hide))
+		}
+		for k, v := range src.Hide {
+			cfg.Hide[k] = v
+		}
+	}
+	if src.SkipNoToken != nil {
+		cfg.SkipNoToken = src.SkipNoToken
+	}
+	if src.Silent != nil {
+		cfg.Silent = src.Silent
+	}
+	if src.ExpandEnv != nil {
+		cfg.ExpandEnv = src.ExpandEnv
+	}
+	// deny lists and masks are accumulated so that a lower layer's secrets are never exposed
+	if src.Env != nil {
+		if cfg.Env == nil {
+			cfg.Env = &EnvFilter{}
+		}
+		if src.Env.Allow != nil {
+			cfg.Env.Allow = src.Env.Allow
+		}
+		cfg.Env.Deny = append(cfg.Env.Deny, src.Env.Deny...)
+	}
+	if src.Mask != nil {
+		if cfg.Mask == nil {
+			cfg.Mask = &Mask{}
+		}
+		cfg.Mask.Env = append(cfg.Mask.Env, src.Mask.Env...)
+		cfg.Mask.Patterns = append(cfg.Mask.Patterns, src.Mask.Patterns...)
+		cfg.Mask.GitLabMaskedVariables = cfg.Mask.GitLabMaskedVariables || src.Mask.GitLabMaskedVariables
+	}
+	cfg.Overrides = append(cfg.Overrides, src.Overrides...)
+}
+
+// mergeValue deep-merges maps. If either value isn't a map, src is returned.
+func mergeValue(dst, src interface{}) interface{} {
+	d, ok := dst.(map[interface{}]interface{})
+	if !ok {
+		return src
+	}
+	s, ok := src.(map[interface{}]interface{})
+	if !ok {
+		return src
+	}
+	ret := make(map[interface{}]interface{}, len(d)+len(s))
+	for k, v := range d {
+		ret[k] = v
+	}
+	for k, v := range s {
+		ret[k] = mergeValue(d[k], v)
+	}
+	return ret
+}
@@ -0,0 +1,157 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yuyaban/gitlab-comment/pkg/expr"
+)
+
+func TestConfig_ApplyOverrides(t *testing.T) { //nolint:funlen
+	t.Parallel()
+	type ciContext struct {
+		Branch   string
+		MRLabels []string
+	}
+	data := []struct {
+		title  string
+		cfg    *Config
+		params *ciContext
+		exp    *Config
+		isErr  bool
+	}{
+		{
+			title: "no override",
+			cfg: &Config{
+				Templates: map[string]string{"foo": "foo"},
+			},
+			params: &ciContext{},
+			exp: &Config{
+				Templates: map[string]string{"foo": "foo"},
+			},
+		},
+		{
+			title: "override isn't matched",
+			cfg: &Config{
+				Templates: map[string]string{"foo": "foo"},
+				Overrides: []*Override{
+					{
+						If: `Branch == "main"`,
+						Config: Config{
+							Templates: map[string]string{"foo": "bar"},
+						},
+					},
+				},
+			},
+			params: &ciContext{
+				Branch: "feature",
+			},
+			exp: &Config{
+				Templates: map[string]string{"foo": "foo"},
+			},
+		},
+		{
+			title: "matched overrides are deep-merged in order",
+			cfg: &Config{
+				Vars: map[string]interface{}{
+					"env": "dev",
+					"nested": map[interface{}]interface{}{
+						"a": 1,
+						"b": 2,
+					},
+				},
+				Templates: map[string]string{"foo": "foo", "bar": "bar"},
+				Exec: map[string][]*ExecConfig{
+					"default": {{When: "true", Template: "base"}},
+				},
+				Overrides: []*Override{
+					{
+						If: `Branch == "main"`,
+						Config: Config{
+							Vars: map[string]interface{}{
+								"env": "prod",
+								"nested": map[interface{}]interface{}{
+									"b": 3,
+								},
+							},
+							Templates: map[string]string{"foo": "main"},
+						},
+					},
+					{
+						If: `"release" in MRLabels`,
+						Config: Config{
+							Templates: map[string]string{"foo": "release"},
+							Exec: map[string][]*ExecConfig{
+								"default": {{When: "ExitCode != 0", Template: "release"}},
+							},
+						},
+					},
+				},
+			},
+			params: &ciContext{
+				Branch:   "main",
+				MRLabels: []string{"release"},
+			},
+			exp: &Config{
+				Vars: map[string]interface{}{
+					"env": "prod",
+					"nested": map[interface{}]interface{}{
+						"a": 1,
+						"b": 3,
+					},
+				},
+				Templates: map[string]string{"foo": "release", "bar": "bar"},
+				Exec: map[string][]*ExecConfig{
+					"default": {{When: "ExitCode != 0", Template: "release"}},
+				},
+			},
+		},
+		{
+			title: "override turns off bool settings",
+			cfg: &Config{
+				SkipNoToken: boolPtr(true),
+				Silent:      boolPtr(true),
+				Overrides: []*Override{
+					{
+						If: `Branch == "main"`,
+						Config: Config{
+							Silent: boolPtr(false),
+						},
+					},
+				},
+			},
+			params: &ciContext{
+				Branch: "main",
+			},
+			exp: &Config{
+				SkipNoToken: boolPtr(true),
+				Silent:      boolPtr(false),
+			},
+		},
+		{
+			title: "if is required",
+			cfg: &Config{
+				Overrides: []*Override{{}},
+			},
+			params: &ciContext{},
+			isErr:  true,
+		},
+	}
+	for _, d := range data {
+		d := d
+		t.Run(d.title, func(t *testing.T) {
+			t.Parallel()
+			err := d.cfg.ApplyOverrides(&expr.Expr{}, d.params)
+			if d.isErr {
+				require.NotNil(t, err)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, d.exp, d.cfg)
+		})
+	}
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
@@ -0,0 +1,151 @@
+package execute
+
+import (
+	"bytes"
+	"strconv"
+	"sync"
+)
+
+// capture is an io.Writer which keeps the head and the tail of the written data up to the limit.
+// The middle of the data is dropped, so the memory usage doesn't depend on the size of the data.
+// If the limit is zero or negative, all data is kept.
+// It is safe for concurrent use because the standard output and the standard error output are written to the same capture.
+type capture struct {
+	mutex     sync.Mutex
+	limit     int
+	headLimit int
+	head      []byte
+	// tail is a ring buffer
+	tail      []byte
+	tailStart int
+	tailFull  bool
+	// beforeTail is the byte just before the tail
+	beforeTail byte
+	total      int64
+	newLines   int
+}
+
+func newCapture(limit int) *capture {
+	return &capture{
+		limit:      limit,
+		headLimit:  limit / 2, //nolint:gomnd
+		beforeTail: '\n',
+	}
+}
+
+func (c *capture) Write(p []byte) (int, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	n := len(p)
+	c.total += int64(n)
+	c.newLines += bytes.Count(p, []byte{'\n'})
+	if c.limit <= 0 {
+		c.head = append(c.head, p...)
+		return n, nil
+	}
+	if rest := c.headLimit - len(c.head); rest > 0 {
+		if len(p) <= rest {
+			c.head = append(c.head, p...)
+			c.beforeTail = c.head[len(c.head)-1]
+			return n, nil
+		}
+		c.head = append(c.head, p[:rest]...)
+		c.beforeTail = c.head[len(c.head)-1]
+		p = p[rest:]
+	}
+	c.writeTail(p)
+	return n, nil
+}
+
+func (c *capture) writeTail(p []byte) {
+	tailLimit := c.limit - c.headLimit
+	if len(p) >= tailLimit {
+		if len(p) > tailLimit {
+			c.beforeTail = p[len(p)-tailLimit-1]
+		} else if tail := c.tailBytes(); len(tail) != 0 {
+			c.beforeTail = tail[len(tail)-1]
+		}
+		c.tail = append(c.tail[:0], p[len(p)-tailLimit:]...)
+		c.tailStart = 0
+		c.tailFull = true
+		return
+	}
+	if !c.tailFull {
+		if len(c.tail)+len(p) <= tailLimit {
+			c.tail = append(c.tail, p...)
+			return
+		}
+		// fill the buffer and wrap around
+		rest := tailLimit - len(c.tail)
+		c.tail = append(c.tail, p[:rest]...)
+		p = p[rest:]
+		c.tailFull = true
+	}
+	for len(p) > 0 {
+		m := len(c.tail) - c.tailStart
+		if len(p) < m {
+			m = len(p)
+		}
+		// the last evicted byte is just before the new oldest byte
+		c.beforeTail = c.tail[c.tailStart+m-1]
+		copy(c.tail[c.tailStart:], p[:m])
+		p = p[m:]
+		c.tailStart = (c.tailStart + m) % tailLimit
+	}
+}
+
+func (c *capture) tailBytes() []byte {
+	if !c.tailFull {
+		return c.tail
+	}
+	ret := make([]byte, 0, len(c.tail))
+	ret = append(ret, c.tail[c.tailStart:]...)
+	return append(ret, c.tail[:c.tailStart]...)
+}
+
+// Truncated returns true if a part of the data is dropped.
+func (c *capture) Truncated() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.truncated()
+}
+
+func (c *capture) truncated() bool {
+	return int64(len(c.head)+len(c.tail)) < c.total
+}
+
+// result returns the kept data and the number of omitted bytes.
+// If the data is truncated, the head and the tail are aligned to line boundaries
+// and joined with a line which describes how many lines are omitted.
+// If a line is too long to be aligned, it's cut in the middle and "…" is put at the cut.
+// The api package depends on the format to mask a part of a secret at the cut.
+func (c *capture) result() (string, int64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if !c.truncated() {
+		return string(c.head) + string(c.tailBytes()), 0
+	}
+	head := c.head
+	if i := bytes.LastIndexByte(head, '\n'); i >= 0 {
+		head = head[:i+1]
+	}
+	tail := c.tailBytes()
+	tailCut := false
+	if c.beforeTail != '\n' {
+		if i := bytes.IndexByte(tail, '\n'); i >= 0 && i < len(tail)-1 {
+			tail = tail[i+1:]
+		} else {
+			tailCut = true
+		}
+	}
+	omittedBytes := c.total - int64(len(head)+len(tail))
+	omittedLines := c.newLines - bytes.Count(head, []byte{'\n'}) - bytes.Count(tail, []byte{'\n'})
+	marker := "… " + strconv.Itoa(omittedLines) + " lines omitted …\n"
+	if len(head) != 0 && head[len(head)-1] != '\n' {
+		marker = "…\n" + marker
+	}
+	if tailCut {
+		marker += "…"
+	}
+	return string(head) + marker + string(tail), omittedBytes
+}
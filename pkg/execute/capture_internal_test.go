@@ -0,0 +1,108 @@
+package execute
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapture(t *testing.T) { //nolint:funlen
+	t.Parallel()
+	data := []struct {
+		title        string
+		limit        int
+		inputs       []string
+		exp          string
+		truncated    bool
+		omittedBytes int64
+	}{
+		{
+			title:  "unlimited",
+			limit:  0,
+			inputs: []string{"foo\n", "bar\n"},
+			exp:    "foo\nbar\n",
+		},
+		{
+			title:  "not truncated",
+			limit:  100,
+			inputs: []string{"foo\n", "bar\n"},
+			exp:    "foo\nbar\n",
+		},
+		{
+			title:  "exactly the limit",
+			limit:  8,
+			inputs: []string{"foo\n", "bar\n"},
+			exp:    "foo\nbar\n",
+		},
+		{
+			title:        "truncated",
+			limit:        12,
+			inputs:       []string{"1\n2\n", "3\n4\n5\n", "6\n7\n8\n9\n"},
+			exp:          "1\n2\n3\n… 3 lines omitted …\n7\n8\n9\n",
+			truncated:    true,
+			omittedBytes: 6,
+		},
+		{
+			title:        "one large write",
+			limit:        8,
+			inputs:       []string{"1\n2\n3\n4\n5\n6\n7\n8\n9\n"},
+			exp:          "1\n2\n… 5 lines omitted …\n8\n9\n",
+			truncated:    true,
+			omittedBytes: 10,
+		},
+		{
+			title:        "no new line",
+			limit:        4,
+			inputs:       []string{"abcdefghij"},
+			exp:          "ab…\n… 0 lines omitted …\n…ij",
+			truncated:    true,
+			omittedBytes: 6,
+		},
+		{
+			title:        "the first line of the tail is too long",
+			limit:        8,
+			inputs:       []string{"1\n2\n", "abcdefgh"},
+			exp:          "1\n2\n… 0 lines omitted …\n…efgh",
+			truncated:    true,
+			omittedBytes: 4,
+		},
+	}
+	for _, d := range data {
+		d := d
+		t.Run(d.title, func(t *testing.T) {
+			t.Parallel()
+			c := newCapture(d.limit)
+			for _, input := range d.inputs {
+				n, err := c.Write([]byte(input))
+				require.Nil(t, err)
+				require.Equal(t, len(input), n)
+			}
+			s, omittedBytes := c.result()
+			require.Equal(t, d.exp, s)
+			require.Equal(t, d.truncated, c.Truncated())
+			require.Equal(t, d.omittedBytes, omittedBytes)
+		})
+	}
+}
+
+func TestCapture_concurrentWrite(t *testing.T) {
+	t.Parallel()
+	c := newCapture(0)
+	var wg sync.WaitGroup
+	for _, s := range []string{"out\n", "err\n"} {
+		s := s
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				c.Write([]byte(s)) //nolint:errcheck
+			}
+		}()
+	}
+	wg.Wait()
+	s, _ := c.result()
+	require.Equal(t, 1000, strings.Count(s, "out\n"))
+	require.Equal(t, 1000, strings.Count(s, "err\n"))
+}
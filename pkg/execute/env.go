@@ -0,0 +1,39 @@
+package execute
+
+import (
+	"path"
+	"strings"
+)
+
+// FilterEnv filters environment variables by glob patterns of their names.
+// If allow is empty, all environment variables are allowed. deny takes precedence over allow.
+func FilterEnv(environ, allow, deny []string) []string {
+	if len(allow) == 0 && len(deny) == 0 {
+		return environ
+	}
+	ret := make([]string, 0, len(environ))
+	for _, kv := range environ {
+		name := kv
+		if i := strings.Index(kv, "="); i >= 0 {
+			name = kv[:i]
+		}
+		if len(allow) != 0 && !MatchName(name, allow) {
+			continue
+		}
+		if MatchName(name, deny) {
+			continue
+		}
+		ret = append(ret, kv)
+	}
+	return ret
+}
+
+// MatchName returns true if the name matches with any of glob patterns.
+func MatchName(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if f, err := path.Match(pattern, name); err == nil && f {
+			return true
+		}
+	}
+	return false
+}
@@ -1,16 +1,21 @@
 package execute
 
 import (
-	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"os"
 	"os/exec"
+	"syscall"
+	"time"
 
 	"github.com/mattn/go-colorable"
-	"github.com/suzuki-shunsuke/go-timeout/timeout"
 )
 
+// timeoutExitCode is the exit code when the command times out. It is same as timeout(1)
+const timeoutExitCode = 124
+
 type Executor struct {
 	Stdout io.Writer
 	Stderr io.Writer
@@ -18,41 +23,189 @@ type Executor struct {
 }
 
 type Result struct {
+	// ExitCode is 128 + the signal number if the command is terminated by a signal, and 124 if the command times out
 	ExitCode       int
 	Cmd            string
 	Stdout         string
 	Stderr         string
 	CombinedOutput string
+	// RawCombinedOutput is the combined output including ANSI escape sequences such as colors
+	RawCombinedOutput string
+	// TimedOut is true if the command is terminated because of the timeout
+	TimedOut bool
+	// Canceled is true if the command is terminated because gitlab-comment receives a signal such as SIGTERM
+	Canceled bool
+	// StartTime and EndTime are times when the command starts and exits
+	StartTime time.Time
+	EndTime   time.Time
+	Duration  time.Duration
+	// UserTime and SystemTime are CPU times of the command
+	UserTime   time.Duration
+	SystemTime time.Duration
+	// MaxRSS is the peak resident set size of the command in bytes. It is zero on Windows
+	MaxRSS int64
+	// Signal is the name of the signal which terminated the command such as "SIGKILL".
+	// If the command exited normally, Signal is empty
+	Signal string
+	// Output is lines of the standard output and the standard error output with their streams and times.
+	// If the total size exceeds MaxOutputSize, the oldest lines are dropped.
+	// If the command runs under a pseudo-terminal, all lines are labeled as the standard output
+	// because the standard output and the standard error output can't be distinguished
+	Output             Output
+	OutputOmittedBytes int64
+	// StdoutTruncated is true if the middle of the standard output is omitted because of MaxOutputSize
+	StdoutTruncated            bool
+	StderrTruncated            bool
+	CombinedOutputTruncated    bool
+	StdoutOmittedBytes         int64
+	StderrOmittedBytes         int64
+	CombinedOutputOmittedBytes int64
 }
 
 type Params struct {
 	Cmd   string
 	Args  []string
 	Stdin io.Reader
+	// Timeout is the timeout of the command. If it is zero, the command never times out
+	Timeout time.Duration
+	// GracePeriod is the duration from SIGTERM to SIGKILL when the command is terminated.
+	// If it is zero, SIGKILL isn't sent
+	GracePeriod time.Duration
+	// Stdout and Stderr are writers to which the command's outputs are written as they are.
+	// If they are nil, Executor.Stdout and Executor.Stderr are used
+	Stdout io.Writer
+	Stderr io.Writer
+	// MaxOutputSize is the maximum size of each captured output in bytes.
+	// The head and the tail of the output are kept and the middle is omitted.
+	// If it is zero or negative, the whole output is kept
+	MaxOutputSize int
+	// Output is a writer to which the uncolored combined output is written while the command is running.
+	// If it is nil, the output is only captured
+	Output io.Writer
+	// Dir is the working directory of the command. If it is empty, the current directory is used
+	Dir string
+	// Env are environment variables which are added to Executor.Env. The format is "<name>=<value>"
+	Env []string
+	// PTY runs the command under a pseudo-terminal. It is supported only on Linux.
+	// The standard output and the standard error output are merged, so Result.Stderr is empty.
+	// Carriage returns in captured outputs are normalized
+	PTY bool
 }
 
 func (executor *Executor) Run(ctx context.Context, params *Params) (*Result, error) {
 	cmd := exec.Command(params.Cmd, params.Args...) //nolint:gosec
 	cmd.Stdin = params.Stdin
-	stdout := &bytes.Buffer{}
-	stderr := &bytes.Buffer{}
-	combinedOutput := &bytes.Buffer{}
+	stdout := newCapture(params.MaxOutputSize)
+	stderr := newCapture(params.MaxOutputSize)
+	combinedOutput := newCapture(params.MaxOutputSize)
+	rawCombinedOutput := newCapture(params.MaxOutputSize)
 	uncolorizedStdout := colorable.NewNonColorable(stdout)
 	uncolorizedStderr := colorable.NewNonColorable(stderr)
 	uncolorizedCombinedOutput := colorable.NewNonColorable(combinedOutput)
-	cmd.Stdout = io.MultiWriter(executor.Stdout, uncolorizedStdout, uncolorizedCombinedOutput)
-	cmd.Stderr = io.MultiWriter(executor.Stderr, uncolorizedStderr, uncolorizedCombinedOutput)
+	recorder := newOutputRecorder(time.Now(), params.MaxOutputSize)
+	teeStdout := executor.Stdout
+	if params.Stdout != nil {
+		teeStdout = params.Stdout
+	}
+	teeStderr := executor.Stderr
+	if params.Stderr != nil {
+		teeStderr = params.Stderr
+	}
+	stdoutWriters := []io.Writer{
+		teeStdout, uncolorizedStdout, uncolorizedCombinedOutput, rawCombinedOutput,
+		colorable.NewNonColorable(recorder.writer(StreamStdout)),
+	}
+	stderrWriters := []io.Writer{
+		teeStderr, uncolorizedStderr, uncolorizedCombinedOutput, rawCombinedOutput,
+		colorable.NewNonColorable(recorder.writer(StreamStderr)),
+	}
+	if params.Output != nil {
+		output := colorable.NewNonColorable(params.Output)
+		stdoutWriters = append(stdoutWriters, output)
+		stderrWriters = append(stderrWriters, output)
+	}
+	cmd.Dir = params.Dir
 	cmd.Env = executor.Env
+	if len(params.Env) != 0 {
+		base := executor.Env
+		if base == nil {
+			// if cmd.Env is nil, the command inherits the current process's environment variables
+			base = os.Environ()
+		}
+		cmd.Env = append(append(make([]string, 0, len(base)+len(params.Env)), base...), params.Env...)
+	}
+	var session *ptySession
+	// a new session created for the pseudo-terminal is also a new process group
+	group := true
+	if params.PTY {
+		s, err := startPTYSession(cmd, params.Stdin, io.MultiWriter(stdoutWriters...))
+		if err != nil {
+			return &Result{
+				ExitCode: -1,
+				Cmd:      cmd.String(),
+			}, err
+		}
+		session = s
+	} else {
+		cmd.Stdout = io.MultiWriter(stdoutWriters...)
+		cmd.Stderr = io.MultiWriter(stderrWriters...)
+		group = setProcessGroup(cmd)
+	}
 
-	runner := timeout.NewRunner(0)
-	err := runner.Run(ctx, cmd)
-	ec := cmd.ProcessState.ExitCode()
+	runner := newRunner(params.GracePeriod)
+	startTime := time.Now()
+	recorder.setStartTime(startTime)
+	var timer *time.Timer
+	if params.Timeout > 0 {
+		timer = time.AfterFunc(params.Timeout, func() {
+			runner.sendSignal(syscall.SIGTERM)
+		})
+	}
+	// The signal which cancels ctx is forwarded to the command
+	stopForwarding := forwardSignal(ctx, runner)
+	err := runner.run(cmd, group)
+	endTime := time.Now()
+	stopForwarding()
+	if session != nil {
+		session.close()
+	}
 	result := &Result{
-		ExitCode:       ec,
-		Cmd:            cmd.String(),
-		Stdout:         stdout.String(),
-		Stderr:         stderr.String(),
-		CombinedOutput: combinedOutput.String(),
+		ExitCode:                cmd.ProcessState.ExitCode(),
+		Cmd:                     cmd.String(),
+		StdoutTruncated:         stdout.Truncated(),
+		StderrTruncated:         stderr.Truncated(),
+		CombinedOutputTruncated: combinedOutput.Truncated(),
+	}
+	setUsage(result, cmd.ProcessState, startTime, endTime)
+	// the exit code of the command terminated by a signal is -1, so it's converted like shells
+	if code, ok := signalExitCode(cmd.ProcessState); ok {
+		result.ExitCode = code
+	}
+	result.Stdout, result.StdoutOmittedBytes = stdout.result()
+	result.Stderr, result.StderrOmittedBytes = stderr.result()
+	result.CombinedOutput, result.CombinedOutputOmittedBytes = combinedOutput.result()
+	result.RawCombinedOutput, _ = rawCombinedOutput.result()
+	result.Output, result.OutputOmittedBytes = recorder.result()
+	if params.PTY {
+		result.Stdout = normalizeTerminalOutput(result.Stdout)
+		result.CombinedOutput = normalizeTerminalOutput(result.CombinedOutput)
+		result.RawCombinedOutput = normalizeTerminalOutput(result.RawCombinedOutput)
+	}
+	// If the timer has already fired, Stop returns false
+	if timer != nil && !timer.Stop() {
+		result.TimedOut = true
+		result.ExitCode = timeoutExitCode
+		if err == nil {
+			err = errors.New("the command exited after SIGTERM")
+		}
+		return result, fmt.Errorf("run a command: timed out after %s: %w", params.Timeout, err)
+	}
+	if ctx.Err() != nil {
+		result.Canceled = true
+		if err == nil {
+			err = errors.New("the command exited after " + forwardedSignal(ctx).String())
+		}
+		return result, fmt.Errorf("run a command: canceled: %w", err)
 	}
 	if err == nil {
 		return result, nil
@@ -0,0 +1,31 @@
+//go:build !windows
+
+package execute
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecutor_Run_concurrentOutput(t *testing.T) {
+	t.Parallel()
+	executor := &Executor{
+		Stdout: io.Discard,
+		Stderr: io.Discard,
+	}
+	result, err := executor.Run(context.Background(), &Params{
+		Cmd: "sh",
+		Args: []string{
+			"-c",
+			`for i in $(seq 500); do echo out; done & for i in $(seq 500); do echo err >&2; done; wait`,
+		},
+	})
+	require.Nil(t, err)
+	require.Equal(t, 500, strings.Count(result.CombinedOutput, "out\n"))
+	require.Equal(t, 500, strings.Count(result.CombinedOutput, "err\n"))
+	require.Equal(t, 500, strings.Count(result.RawCombinedOutput, "err\n"))
+}
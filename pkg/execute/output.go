@@ -0,0 +1,186 @@
+package execute
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	StreamStdout = "stdout"
+	StreamStderr = "stderr"
+)
+
+// OutputLine is a line of the command's output.
+type OutputLine struct {
+	// Stream is either "stdout" or "stderr"
+	Stream string
+	// Elapsed is the duration from the start of the command to when the line starts to be written
+	Elapsed time.Duration
+	// Text is the line without the trailing newline and ANSI escape sequences
+	Text string
+}
+
+// Output is lines of the standard output and the standard error output in the order they are written.
+type Output []*OutputLine
+
+func (output Output) filter(stream string) Output {
+	var ret Output
+	for _, line := range output {
+		if line.Stream == stream {
+			ret = append(ret, line)
+		}
+	}
+	return ret
+}
+
+// Stdout returns lines of the standard output.
+func (output Output) Stdout() Output {
+	return output.filter(StreamStdout)
+}
+
+// Stderr returns lines of the standard error output.
+func (output Output) Stderr() Output {
+	return output.filter(StreamStderr)
+}
+
+// Shift returns a copy of the output whose Elapsed are increased by d.
+// It's used to merge outputs of commands which start at different times.
+func (output Output) Shift(d time.Duration) Output {
+	ret := make(Output, len(output))
+	for i, line := range output {
+		l := *line
+		l.Elapsed += d
+		ret[i] = &l
+	}
+	return ret
+}
+
+// SortByElapsed sorts lines by Elapsed. Lines written at the same time keep their order.
+func (output Output) SortByElapsed() {
+	sort.SliceStable(output, func(i, j int) bool {
+		return output[i].Elapsed < output[j].Elapsed
+	})
+}
+
+type partialLine struct {
+	buf     []byte
+	elapsed time.Duration
+}
+
+// outputRecorder records lines of outputs with their streams and times.
+// If the total size of lines exceeds the limit, the oldest lines are dropped.
+// If the limit is zero or negative, all lines are kept.
+type outputRecorder struct {
+	mutex     sync.Mutex
+	startTime time.Time
+	limit     int
+	lines     Output
+	size      int
+	omitted   int64
+	partial   map[string]*partialLine
+}
+
+func newOutputRecorder(startTime time.Time, limit int) *outputRecorder {
+	return &outputRecorder{
+		startTime: startTime,
+		limit:     limit,
+		partial:   map[string]*partialLine{},
+	}
+}
+
+// setStartTime sets the time from which Elapsed of lines is measured.
+// It is locked because the output of a pseudo-terminal is copied before the command starts.
+func (recorder *outputRecorder) setStartTime(startTime time.Time) {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	recorder.startTime = startTime
+}
+
+type streamWriter struct {
+	recorder *outputRecorder
+	stream   string
+}
+
+func (writer *streamWriter) Write(p []byte) (int, error) {
+	writer.recorder.write(writer.stream, p)
+	return len(p), nil
+}
+
+func (recorder *outputRecorder) writer(stream string) io.Writer {
+	return &streamWriter{
+		recorder: recorder,
+		stream:   stream,
+	}
+}
+
+func (recorder *outputRecorder) write(stream string, p []byte) {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	elapsed := time.Since(recorder.startTime)
+	for len(p) != 0 {
+		line, ok := recorder.partial[stream]
+		if !ok {
+			line = &partialLine{
+				elapsed: elapsed,
+			}
+			recorder.partial[stream] = line
+		}
+		idx := bytes.IndexByte(p, '\n')
+		if idx < 0 {
+			line.buf = appendLimited(line.buf, p, recorder.limit)
+			return
+		}
+		line.buf = appendLimited(line.buf, p[:idx], recorder.limit)
+		recorder.add(stream, line)
+		delete(recorder.partial, stream)
+		p = p[idx+1:]
+	}
+}
+
+// appendLimited appends p to buf up to the limit, so that a very long line doesn't exhaust the memory.
+func appendLimited(buf, p []byte, limit int) []byte {
+	if limit > 0 && len(buf)+len(p) > limit {
+		return append(buf, p[:limit-len(buf)]...)
+	}
+	return append(buf, p...)
+}
+
+func (recorder *outputRecorder) add(stream string, line *partialLine) {
+	text := string(line.buf)
+	// a line rewritten with carriage returns such as a progress bar is collapsed into the last content
+	text = strings.TrimRight(text, "\r")
+	if idx := strings.LastIndex(text, "\r"); idx >= 0 {
+		text = text[idx+1:]
+	}
+	recorder.lines = append(recorder.lines, &OutputLine{
+		Stream:  stream,
+		Elapsed: line.elapsed,
+		Text:    text,
+	})
+	recorder.size += len(text)
+	for recorder.limit > 0 && recorder.size > recorder.limit && len(recorder.lines) > 1 {
+		n := len(recorder.lines[0].Text)
+		recorder.size -= n
+		recorder.omitted += int64(n)
+		recorder.lines = recorder.lines[1:]
+	}
+}
+
+// result returns recorded lines and the size of dropped lines.
+// Lines without the trailing newline are also returned.
+func (recorder *outputRecorder) result() (Output, int64) {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	for _, stream := range []string{StreamStdout, StreamStderr} {
+		if line, ok := recorder.partial[stream]; ok {
+			recorder.add(stream, line)
+			delete(recorder.partial, stream)
+		}
+	}
+	recorder.lines.SortByElapsed()
+	return recorder.lines, recorder.omitted
+}
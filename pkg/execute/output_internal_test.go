@@ -0,0 +1,59 @@
+package execute
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_outputRecorder(t *testing.T) {
+	t.Parallel()
+	data := []struct {
+		title   string
+		limit   int
+		writes  [][2]string
+		exp     []string
+		omitted int64
+	}{
+		{
+			title: "lines of streams",
+			writes: [][2]string{
+				{StreamStdout, "foo\nba"},
+				{StreamStdout, "r\n"},
+				{StreamStderr, "error\n"},
+				{StreamStdout, "10%\r100%\r\n"},
+				{StreamStderr, "no newline"},
+			},
+			exp: []string{"stdout:foo", "stdout:bar", "stderr:error", "stdout:100%", "stderr:no newline"},
+		},
+		{
+			title: "oldest lines are dropped",
+			limit: 8,
+			writes: [][2]string{
+				{StreamStdout, "aaa\nbbb\nccc\n"},
+			},
+			exp:     []string{"stdout:bbb", "stdout:ccc"},
+			omitted: 3,
+		},
+	}
+	for _, d := range data {
+		d := d
+		t.Run(d.title, func(t *testing.T) {
+			t.Parallel()
+			recorder := newOutputRecorder(time.Now(), d.limit)
+			for _, w := range d.writes {
+				_, err := recorder.writer(w[0]).Write([]byte(w[1]))
+				require.Nil(t, err)
+			}
+			output, omitted := recorder.result()
+			lines := make([]string, len(output))
+			for i, line := range output {
+				lines[i] = line.Stream + ":" + line.Text
+			}
+			require.Equal(t, d.exp, lines)
+			require.Equal(t, d.omitted, omitted)
+			require.Len(t, output.Stderr(), len(output)-len(output.Stdout()))
+		})
+	}
+}
@@ -0,0 +1,39 @@
+//go:build !windows
+
+package execute
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"golang.org/x/term"
+)
+
+// setProcessGroup runs the command in a new process group,
+// so that signals to terminate the command and its children don't reach gitlab-comment itself.
+// If the standard input is a terminal, the command is kept in the foreground process group
+// because a background process can't read the terminal.
+// It returns true if a new process group is created.
+func setProcessGroup(cmd *exec.Cmd) bool {
+	if f, ok := cmd.Stdin.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		return false
+	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	return true
+}
+
+// signalProcess sends the signal to the process group of the process if group is true, otherwise to the process.
+func signalProcess(proc *os.Process, group bool, sig syscall.Signal) error {
+	if !group {
+		if err := proc.Signal(sig); err != nil && !errors.Is(err, os.ErrProcessDone) {
+			return err //nolint:wrapcheck
+		}
+		return nil
+	}
+	if err := syscall.Kill(-proc.Pid, sig); err != nil && !errors.Is(err, syscall.ESRCH) {
+		return err //nolint:wrapcheck
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+package execute
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+)
+
+func setProcessGroup(cmd *exec.Cmd) bool {
+	return false
+}
+
+// signalProcess kills the process tree because Windows doesn't support signals.
+func signalProcess(proc *os.Process, group bool, sig syscall.Signal) error {
+	return exec.Command("taskkill", "/F", "/T", "/PID", strconv.Itoa(proc.Pid)).Run() //nolint:gosec,wrapcheck
+}
@@ -0,0 +1,143 @@
+package execute
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// ptyDrainTimeout is the maximum time to wait for the rest of the output after the command exits.
+// If a background process of the command keeps the pseudo-terminal open, the output is read until the timeout
+const ptyDrainTimeout = 3 * time.Second
+
+// eot is the character which makes the command read EOF from the pseudo-terminal
+const eot = 0x04
+
+// ptySession runs a command under a pseudo-terminal and copies the command's output.
+type ptySession struct {
+	master *os.File
+	tty    *os.File
+	copied chan struct{}
+	// closed is closed when the session is closed
+	closed chan struct{}
+	// stdinCopied is closed when copying the standard input finishes.
+	// It is nil if copying the standard input can't be interrupted
+	stdinCopied chan struct{}
+	// closeStdin interrupts copying the standard input
+	closeStdin func()
+}
+
+// startPTYSession connects the command to a new pseudo-terminal.
+// The command's standard output and standard error output are merged and written to output.
+// stdin is written to the pseudo-terminal. If it is nil, the command reads EOF like /dev/null.
+func startPTYSession(cmd *exec.Cmd, stdin io.Reader, output io.Writer) (*ptySession, error) {
+	master, tty, err := openPTY()
+	if err != nil {
+		return nil, fmt.Errorf("open a pseudo-terminal: %w", err)
+	}
+	cmd.Stdin = tty
+	cmd.Stdout = tty
+	cmd.Stderr = tty
+	setControllingTerminal(cmd)
+	session := &ptySession{
+		master: master,
+		tty:    tty,
+		copied: make(chan struct{}),
+		closed: make(chan struct{}),
+	}
+	go func() {
+		// Read fails with EIO when the pseudo-terminal is closed
+		_, _ = io.Copy(output, master)
+		close(session.copied)
+	}()
+	if stdin == nil {
+		stdin = strings.NewReader("")
+	}
+	{
+		var copied chan struct{}
+		// a file such as os.Stdin is read through a non-blocking duplicate, so that closing the session interrupts reading it.
+		// Other readers are expected to reach EOF
+		if f, ok := stdin.(*os.File); ok {
+			if dup, closeDup, err := interruptibleFile(f); err == nil {
+				stdin = dup
+				session.closeStdin = closeDup
+				copied = make(chan struct{})
+				session.stdinCopied = copied
+			}
+		}
+		go session.copyStdin(stdin, copied)
+	}
+	return session, nil
+}
+
+// copyStdin writes stdin to the pseudo-terminal and then sends EOT so that the command reads EOF.
+// If copied isn't nil, it is closed when copying finishes.
+func (session *ptySession) copyStdin(stdin io.Reader, copied chan struct{}) {
+	if copied != nil {
+		defer close(copied)
+	}
+	w := &lastByteWriter{w: session.master}
+	_, _ = io.Copy(w, stdin)
+	select {
+	case <-session.closed:
+		return
+	default:
+	}
+	// EOT makes the command read EOF only at the beginning of a line.
+	// Otherwise the first EOT just flushes the partial line
+	eots := []byte{eot}
+	if w.written && w.last != '\n' {
+		eots = append(eots, eot)
+	}
+	_, _ = session.master.Write(eots)
+}
+
+// lastByteWriter records the last written byte.
+type lastByteWriter struct {
+	w       io.Writer
+	last    byte
+	written bool
+}
+
+func (writer *lastByteWriter) Write(p []byte) (int, error) {
+	n, err := writer.w.Write(p)
+	if n > 0 {
+		writer.last = p[n-1]
+		writer.written = true
+	}
+	return n, err //nolint:wrapcheck
+}
+
+// close waits for the rest of the output and closes the pseudo-terminal.
+// It must be called after the command exits.
+func (session *ptySession) close() {
+	close(session.closed)
+	if session.closeStdin != nil {
+		session.closeStdin()
+		<-session.stdinCopied
+	}
+	session.tty.Close()
+	select {
+	case <-session.copied:
+	case <-time.After(ptyDrainTimeout):
+	}
+	session.master.Close()
+	<-session.copied
+}
+
+// normalizeTerminalOutput converts the output of a pseudo-terminal to plain text.
+// CRLF is converted to LF, and a line rewritten with carriage returns such as a progress bar is collapsed into the last content.
+func normalizeTerminalOutput(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		line = strings.TrimRight(line, "\r")
+		if idx := strings.LastIndex(line, "\r"); idx >= 0 {
+			line = line[idx+1:]
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,39 @@
+package execute
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_normalizeTerminalOutput(t *testing.T) {
+	t.Parallel()
+	data := []struct {
+		title  string
+		output string
+		exp    string
+	}{
+		{
+			title:  "crlf",
+			output: "foo\r\nbar\r\n",
+			exp:    "foo\nbar\n",
+		},
+		{
+			title:  "progress bar",
+			output: "downloading 10%\rdownloading 50%\rdownloading 100%\r\ndone\r\n",
+			exp:    "downloading 100%\ndone\n",
+		},
+		{
+			title:  "no trailing newline",
+			output: "foo\r\n1/3\r2/3",
+			exp:    "foo\n2/3",
+		},
+	}
+	for _, d := range data {
+		d := d
+		t.Run(d.title, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, d.exp, normalizeTerminalOutput(d.output))
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package execute
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// the window size of the pseudo-terminal. Some commands format their output according to it
+const (
+	ptyRows    = 40
+	ptyColumns = 120
+)
+
+// openPTY opens a pair of a pseudo-terminal master and slave.
+func openPTY() (*os.File, *os.File, error) {
+	// The master is opened in non-blocking mode so that reading it can be interrupted by Close
+	fd, err := unix.Open("/dev/ptmx", unix.O_RDWR|unix.O_NOCTTY|unix.O_CLOEXEC|unix.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open /dev/ptmx: %w", err)
+	}
+	master := os.NewFile(uintptr(fd), "/dev/ptmx")
+	tty, err := openTTY(fd)
+	if err != nil {
+		master.Close()
+		return nil, nil, err
+	}
+	return master, tty, nil
+}
+
+func openTTY(masterFD int) (*os.File, error) {
+	if err := unix.IoctlSetPointerInt(masterFD, unix.TIOCSPTLCK, 0); err != nil {
+		return nil, fmt.Errorf("unlock a pseudo-terminal: %w", err)
+	}
+	n, err := unix.IoctlGetInt(masterFD, unix.TIOCGPTN)
+	if err != nil {
+		return nil, fmt.Errorf("get the pseudo-terminal number: %w", err)
+	}
+	name := "/dev/pts/" + strconv.Itoa(n)
+	tty, err := os.OpenFile(name, os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", name, err)
+	}
+	if err := configureTTY(int(tty.Fd())); err != nil {
+		tty.Close()
+		return nil, err
+	}
+	return tty, nil
+}
+
+func configureTTY(fd int) error {
+	termios, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+	if err != nil {
+		return fmt.Errorf("get attributes of the pseudo-terminal: %w", err)
+	}
+	// disable echo so that the standard input isn't captured as the output
+	termios.Lflag &^= unix.ECHO
+	if err := unix.IoctlSetTermios(fd, unix.TCSETS, termios); err != nil {
+		return fmt.Errorf("set attributes of the pseudo-terminal: %w", err)
+	}
+	if err := unix.IoctlSetWinsize(fd, unix.TIOCSWINSZ, &unix.Winsize{Row: ptyRows, Col: ptyColumns}); err != nil {
+		return fmt.Errorf("set the window size of the pseudo-terminal: %w", err)
+	}
+	return nil
+}
+
+// setControllingTerminal runs the command in a new session whose controlling terminal is the command's standard input.
+// The session is also a new process group, so signals to terminate the command reach its children.
+func setControllingTerminal(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Setsid:  true,
+		Setctty: true,
+	}
+}
+
+// interruptibleFile returns a non-blocking duplicate of f whose Read is interrupted by Close.
+// The returned function closes the duplicate and restores the blocking mode of f,
+// because the mode is shared with f such as the standard input of gitlab-comment.
+func interruptibleFile(f *os.File) (*os.File, func(), error) {
+	fd, err := unix.Dup(int(f.Fd()))
+	if err != nil {
+		return nil, nil, fmt.Errorf("duplicate a file descriptor: %w", err)
+	}
+	flags, err := unix.FcntlInt(uintptr(fd), unix.F_GETFL, 0)
+	if err != nil {
+		unix.Close(fd)
+		return nil, nil, fmt.Errorf("get file status flags: %w", err)
+	}
+	if err := unix.SetNonblock(fd, true); err != nil {
+		unix.Close(fd)
+		return nil, nil, fmt.Errorf("set the non-blocking mode: %w", err)
+	}
+	unix.CloseOnExec(fd)
+	dup := os.NewFile(uintptr(fd), f.Name())
+	return dup, func() {
+		// the duplicate is closed first so that the pending Read returns instead of blocking
+		dup.Close()
+		if flags&unix.O_NONBLOCK == 0 {
+			_ = unix.SetNonblock(int(f.Fd()), false)
+		}
+	}, nil
+}
@@ -0,0 +1,90 @@
+//go:build linux
+
+package execute
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecutor_Run_pty(t *testing.T) { //nolint:funlen
+	t.Parallel()
+	data := []struct {
+		title string
+		args  []string
+		stdin io.Reader
+		exp   string
+	}{
+		{
+			title: "terminal",
+			args: []string{
+				"-c",
+				`if [ -t 0 ] && [ -t 1 ] && [ -t 2 ]; then echo tty; fi; printf 'a\r\nb 10%%\rb 100%%\n'; echo err >&2`,
+			},
+			exp: "tty\na\nb 100%\nerr\n",
+		},
+		{
+			title: "stdin without trailing newline",
+			args:  []string{"-c", "cat; echo"},
+			stdin: strings.NewReader("foo\nbar"),
+			exp:   "foo\nbar\n",
+		},
+	}
+	for _, d := range data {
+		d := d
+		t.Run(d.title, func(t *testing.T) {
+			t.Parallel()
+			executor := &Executor{
+				Stdout: io.Discard,
+				Stderr: io.Discard,
+			}
+			result, err := executor.Run(context.Background(), &Params{
+				Cmd:     "sh",
+				Args:    d.args,
+				Stdin:   d.stdin,
+				PTY:     true,
+				Timeout: 10 * time.Second, //nolint:gomnd
+			})
+			require.Nil(t, err)
+			require.Equal(t, d.exp, result.Stdout)
+			require.Equal(t, d.exp, result.CombinedOutput)
+			require.Empty(t, result.Stderr)
+			for _, line := range result.Output {
+				require.Equal(t, StreamStdout, line.Stream)
+			}
+		})
+	}
+}
+
+func TestExecutor_Run_ptyStdinIsReleased(t *testing.T) {
+	t.Parallel()
+	pr, pw, err := os.Pipe()
+	require.Nil(t, err)
+	defer pr.Close()
+	defer pw.Close()
+	executor := &Executor{
+		Stdout: io.Discard,
+		Stderr: io.Discard,
+	}
+	// the command exits without reading the standard input, which is never closed
+	_, err = executor.Run(context.Background(), &Params{
+		Cmd:   "true",
+		Stdin: pr,
+		PTY:   true,
+	})
+	require.Nil(t, err)
+	// data written after the command exits isn't consumed by gitlab-comment
+	_, err = pw.Write([]byte("after\n"))
+	require.Nil(t, err)
+	require.Nil(t, pr.SetReadDeadline(time.Now().Add(5*time.Second)))
+	b := make([]byte, 6)
+	_, err = io.ReadFull(pr, b)
+	require.Nil(t, err)
+	require.Equal(t, "after\n", string(b))
+}
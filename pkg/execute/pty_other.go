@@ -0,0 +1,19 @@
+//go:build !linux
+
+package execute
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+func openPTY() (*os.File, *os.File, error) {
+	return nil, nil, errors.New("pseudo-terminal is supported only on Linux")
+}
+
+func setControllingTerminal(cmd *exec.Cmd) {}
+
+func interruptibleFile(f *os.File) (*os.File, func(), error) {
+	return nil, nil, errors.New("pseudo-terminal is supported only on Linux")
+}
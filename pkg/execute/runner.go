@@ -0,0 +1,68 @@
+package execute
+
+import (
+	"fmt"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// runner runs a command and sends signals to it.
+// If the command runs in its own process group, signals are sent to the whole group so that they reach its children.
+// Otherwise they are sent only to the command, because the group is gitlab-comment's own one.
+type runner struct {
+	// gracePeriod is the duration from the first signal to SIGKILL. If it is zero, SIGKILL isn't sent
+	gracePeriod time.Duration
+	sig         chan syscall.Signal
+}
+
+func newRunner(gracePeriod time.Duration) *runner {
+	return &runner{
+		gracePeriod: gracePeriod,
+		sig:         make(chan syscall.Signal, 1),
+	}
+}
+
+// sendSignal sends the signal to the running command.
+// If a signal is already pending, the signal is dropped so that callers are never blocked after the command exits.
+func (runner *runner) sendSignal(sig syscall.Signal) {
+	select {
+	case runner.sig <- sig:
+	default:
+	}
+}
+
+// run starts the command and waits for it.
+// group must be true if the command runs in a new process group.
+func (runner *runner) run(cmd *exec.Cmd, group bool) error {
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("start a command: %w", err)
+	}
+	exitCh := make(chan error, 1)
+	go func() {
+		exitCh <- cmd.Wait()
+	}()
+	var killCh <-chan time.Time
+	// if a signal can't be sent, the command is still waited for so that its result is available
+	var sigErr error
+	for {
+		select {
+		case err := <-exitCh:
+			if err == nil {
+				return sigErr
+			}
+			return err //nolint:wrapcheck
+		case sig := <-runner.sig:
+			if err := signalProcess(cmd.Process, group, sig); err != nil && sigErr == nil {
+				sigErr = fmt.Errorf("send %s to the command: %w", sig, err)
+			}
+			if runner.gracePeriod > 0 && killCh == nil {
+				killCh = time.After(runner.gracePeriod)
+			}
+		case <-killCh:
+			if err := signalProcess(cmd.Process, group, syscall.SIGKILL); err != nil && sigErr == nil {
+				sigErr = fmt.Errorf("send SIGKILL to the command: %w", err)
+			}
+		}
+	}
+}
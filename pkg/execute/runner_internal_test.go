@@ -0,0 +1,83 @@
+//go:build !windows
+
+package execute
+
+import (
+	"context"
+	"io"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecutor_Run_timeout(t *testing.T) {
+	t.Parallel()
+	executor := &Executor{
+		Stdout: io.Discard,
+		Stderr: io.Discard,
+	}
+	result, err := executor.Run(context.Background(), &Params{
+		Cmd:     "sleep",
+		Args:    []string{"10"},
+		Timeout: 100 * time.Millisecond, //nolint:gomnd
+	})
+	require.NotNil(t, err)
+	require.True(t, result.TimedOut)
+	require.False(t, result.Canceled)
+	require.Equal(t, 124, result.ExitCode)
+	require.Equal(t, "SIGTERM", result.Signal)
+	require.Less(t, result.Duration, 5*time.Second)
+}
+
+func TestRunner_run(t *testing.T) {
+	t.Parallel()
+	data := []struct {
+		title       string
+		group       bool
+		gracePeriod time.Duration
+		sig         syscall.Signal
+		expSignal   syscall.Signal
+	}{
+		{
+			title:     "new process group",
+			group:     true,
+			sig:       syscall.SIGTERM,
+			expSignal: syscall.SIGTERM,
+		},
+		{
+			// the command shares gitlab-comment's process group, so only the command must be signaled
+			title:     "same process group",
+			sig:       syscall.SIGTERM,
+			expSignal: syscall.SIGTERM,
+		},
+		{
+			title:       "SIGKILL after the grace period",
+			group:       true,
+			gracePeriod: 100 * time.Millisecond, //nolint:gomnd
+			sig:         syscall.SIGCONT,
+			expSignal:   syscall.SIGKILL,
+		},
+	}
+	for _, d := range data {
+		d := d
+		t.Run(d.title, func(t *testing.T) {
+			t.Parallel()
+			cmd := exec.Command("sleep", "10")
+			if d.group {
+				cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+			}
+			runner := newRunner(d.gracePeriod)
+			time.AfterFunc(100*time.Millisecond, func() { //nolint:gomnd
+				runner.sendSignal(d.sig)
+			})
+			require.NotNil(t, runner.run(cmd, d.group))
+			status, ok := cmd.ProcessState.Sys().(syscall.WaitStatus)
+			require.True(t, ok)
+			require.True(t, status.Signaled())
+			require.Equal(t, d.expSignal, status.Signal())
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package execute
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+)
+
+type signalKey struct{}
+
+// NotifyContext returns a context which is canceled when one of signals arrives like signal.NotifyContext.
+// The received signal is recorded in the context and forwarded to running commands.
+func NotifyContext(parent context.Context, signals ...os.Signal) (context.Context, context.CancelFunc) {
+	received := &atomic.Value{}
+	ctx, cancel := context.WithCancel(context.WithValue(parent, signalKey{}, received))
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, signals...)
+	go func() {
+		select {
+		case sig := <-ch:
+			received.Store(sig)
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, func() {
+		signal.Stop(ch)
+		cancel()
+	}
+}
+
+// ReceivedSignal returns the signal which canceled the context created by NotifyContext.
+// If no signal is received, nil is returned.
+func ReceivedSignal(ctx context.Context) os.Signal {
+	received, ok := ctx.Value(signalKey{}).(*atomic.Value)
+	if !ok {
+		return nil
+	}
+	sig, _ := received.Load().(os.Signal)
+	return sig
+}
+
+// forwardedSignal returns the signal which is sent to the command when ctx is canceled.
+// If ctx is canceled by other than signals, SIGTERM is sent.
+func forwardedSignal(ctx context.Context) syscall.Signal {
+	if sig, ok := ReceivedSignal(ctx).(syscall.Signal); ok {
+		return sig
+	}
+	return syscall.SIGTERM
+}
+
+// forwardSignal sends the signal to the command when ctx is canceled.
+// The returned function stops forwarding and must be called after the command exits.
+func forwardSignal(ctx context.Context, runner *runner) func() {
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			runner.sendSignal(forwardedSignal(ctx))
+		case <-done:
+		}
+	}()
+	return func() {
+		close(done)
+	}
+}
@@ -0,0 +1,39 @@
+//go:build !windows
+
+package execute
+
+import (
+	"context"
+	"io"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecutor_Run_canceled(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := NotifyContext(context.Background(), syscall.SIGUSR1)
+	defer cancel()
+	require.Nil(t, ReceivedSignal(ctx))
+	go func() {
+		time.Sleep(100 * time.Millisecond) //nolint:gomnd
+		cancel()
+	}()
+	executor := &Executor{
+		Stdout: io.Discard,
+		Stderr: io.Discard,
+	}
+	result, err := executor.Run(ctx, &Params{
+		Cmd:  "sleep",
+		Args: []string{"10"},
+	})
+	require.NotNil(t, err)
+	require.True(t, result.Canceled)
+	require.False(t, result.TimedOut)
+	// SIGTERM is sent if the context isn't canceled by a signal
+	require.Equal(t, "SIGTERM", result.Signal)
+	require.Equal(t, 143, result.ExitCode)
+	require.Less(t, result.Duration, 5*time.Second)
+}
@@ -0,0 +1,20 @@
+package execute
+
+import (
+	"os"
+	"time"
+)
+
+// setUsage sets the resource usage and the signal of the exited process to the result.
+func setUsage(result *Result, state *os.ProcessState, startTime, endTime time.Time) {
+	result.StartTime = startTime
+	result.EndTime = endTime
+	result.Duration = endTime.Sub(startTime)
+	if state == nil {
+		return
+	}
+	result.UserTime = state.UserTime()
+	result.SystemTime = state.SystemTime()
+	result.MaxRSS = maxRSS(state)
+	result.Signal = signalName(state)
+}
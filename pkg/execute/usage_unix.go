@@ -0,0 +1,65 @@
+//go:build !windows
+
+package execute
+
+import (
+	"os"
+	"runtime"
+	"syscall"
+)
+
+// signalNames are names of signals which commonly terminate commands.
+// syscall.Signal.String returns descriptions such as "terminated" rather than names
+var signalNames = map[syscall.Signal]string{ //nolint:gochecknoglobals
+	syscall.SIGHUP:  "SIGHUP",
+	syscall.SIGINT:  "SIGINT",
+	syscall.SIGQUIT: "SIGQUIT",
+	syscall.SIGABRT: "SIGABRT",
+	syscall.SIGKILL: "SIGKILL",
+	syscall.SIGBUS:  "SIGBUS",
+	syscall.SIGSEGV: "SIGSEGV",
+	syscall.SIGPIPE: "SIGPIPE",
+	syscall.SIGTERM: "SIGTERM",
+}
+
+// signalExitCodeBase is added to the signal number to get the exit code of the command terminated by the signal
+const signalExitCodeBase = 128
+
+// maxRSS returns the peak resident set size of the process in bytes.
+func maxRSS(state *os.ProcessState) int64 {
+	rusage, ok := state.SysUsage().(*syscall.Rusage)
+	if !ok || rusage == nil {
+		return 0
+	}
+	if runtime.GOOS == "darwin" {
+		// ru_maxrss is in bytes on macOS and in kilobytes on the other platforms
+		return int64(rusage.Maxrss)
+	}
+	return int64(rusage.Maxrss) * 1024 //nolint:gomnd
+}
+
+// signalName returns the name of the signal which terminated the process.
+// If the process exited normally, an empty string is returned.
+func signalName(state *os.ProcessState) string {
+	status, ok := state.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() {
+		return ""
+	}
+	if name, ok := signalNames[status.Signal()]; ok {
+		return name
+	}
+	return status.Signal().String()
+}
+
+// signalExitCode returns the exit code of the process terminated by a signal, which is 128 + the signal number like shells.
+// If the process exited normally, false is returned.
+func signalExitCode(state *os.ProcessState) (int, bool) {
+	if state == nil {
+		return 0, false
+	}
+	status, ok := state.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() {
+		return 0, false
+	}
+	return signalExitCodeBase + int(status.Signal()), true
+}
@@ -0,0 +1,61 @@
+//go:build !windows
+
+package execute
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecutor_Run_usage(t *testing.T) {
+	t.Parallel()
+	data := []struct {
+		title    string
+		script   string
+		exitCode int
+		signal   string
+		isErr    bool
+	}{
+		{
+			title:  "exited normally",
+			script: `i=0; while [ "$i" -lt 200000 ]; do i=$((i+1)); done`,
+		},
+		{
+			title:    "killed",
+			script:   `kill -9 $$`,
+			exitCode: 137,
+			signal:   "SIGKILL",
+			isErr:    true,
+		},
+	}
+	for _, d := range data {
+		d := d
+		t.Run(d.title, func(t *testing.T) {
+			t.Parallel()
+			executor := &Executor{
+				Stdout: io.Discard,
+				Stderr: io.Discard,
+			}
+			result, err := executor.Run(context.Background(), &Params{
+				Cmd:  "sh",
+				Args: []string{"-c", d.script},
+			})
+			if d.isErr {
+				require.NotNil(t, err)
+			} else {
+				require.Nil(t, err)
+			}
+			require.Equal(t, d.exitCode, result.ExitCode)
+			require.Equal(t, d.signal, result.Signal)
+			require.Positive(t, result.Duration)
+			require.Equal(t, result.Duration, result.EndTime.Sub(result.StartTime))
+			require.Positive(t, result.MaxRSS)
+			if d.signal == "" {
+				require.Positive(t, result.UserTime+result.SystemTime)
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package execute
+
+import "os"
+
+func maxRSS(state *os.ProcessState) int64 {
+	return 0
+}
+
+func signalName(state *os.ProcessState) string {
+	return ""
+}
+
+func signalExitCode(state *os.ProcessState) (int, bool) {
+	return 0, false
+}
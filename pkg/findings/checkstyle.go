@@ -0,0 +1,43 @@
+package findings
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+type checkstyleResult struct {
+	Files []struct {
+		Name   string `xml:"name,attr"`
+		Errors []struct {
+			Line     int    `xml:"line,attr"`
+			Column   int    `xml:"column,attr"`
+			Severity string `xml:"severity,attr"`
+			Message  string `xml:"message,attr"`
+			Source   string `xml:"source,attr"`
+		} `xml:"error"`
+	} `xml:"file"`
+}
+
+// ParseCheckstyle parses a Checkstyle XML report.
+// File paths are converted to paths relative to wd if possible.
+func ParseCheckstyle(r io.Reader, wd string) (Findings, error) {
+	result := &checkstyleResult{}
+	if err := xml.NewDecoder(r).Decode(result); err != nil {
+		return nil, fmt.Errorf("parse a Checkstyle XML report: %w", err)
+	}
+	var findings Findings
+	for _, file := range result.Files {
+		for _, e := range file.Errors {
+			findings = append(findings, &Finding{
+				File:     normalizePath(file.Name, wd),
+				Line:     e.Line,
+				Column:   e.Column,
+				Severity: normalizeSeverity(e.Severity),
+				Rule:     e.Source,
+				Message:  e.Message,
+			})
+		}
+	}
+	return findings, nil
+}
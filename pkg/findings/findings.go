@@ -0,0 +1,128 @@
+package findings
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// Finding is an issue reported by a linter.
+type Finding struct {
+	File   string
+	Line   int
+	Column int
+	// Severity is either "error", "warning", or "info"
+	Severity string
+	Rule     string
+	Message  string
+	// Tool is the name of the linter if the report has it
+	Tool string
+}
+
+// ID returns an identifier of the finding to compare findings between runs.
+// The line and the column aren't included because they change when the file is edited.
+func (finding *Finding) ID() string {
+	h := sha256.Sum256([]byte(strings.Join([]string{finding.Tool, finding.Rule, finding.File, finding.Message}, "\x00")))
+	return hex.EncodeToString(h[:6]) //nolint:gomnd
+}
+
+type Findings []*Finding
+
+// Counts is the number of findings per severity.
+type Counts struct {
+	Error   int
+	Warning int
+	Info    int
+}
+
+func (findings Findings) Counts() *Counts {
+	counts := &Counts{}
+	for _, finding := range findings {
+		switch finding.Severity {
+		case SeverityError:
+			counts.Error++
+		case SeverityWarning:
+			counts.Warning++
+		default:
+			counts.Info++
+		}
+	}
+	return counts
+}
+
+// FileFindings are findings in the same file.
+type FileFindings struct {
+	File     string
+	Counts   *Counts
+	Findings Findings
+}
+
+// ByFile groups findings by file.
+// Files are sorted by path, and findings in each file are sorted by severity and line.
+func (findings Findings) ByFile() []*FileFindings {
+	m := map[string]Findings{}
+	for _, finding := range findings {
+		m[finding.File] = append(m[finding.File], finding)
+	}
+	ret := make([]*FileFindings, 0, len(m))
+	for file, fs := range m {
+		sort.SliceStable(fs, func(i, j int) bool {
+			if a, b := severityRank(fs[i].Severity), severityRank(fs[j].Severity); a != b {
+				return a < b
+			}
+			return fs[i].Line < fs[j].Line
+		})
+		ret = append(ret, &FileFindings{
+			File:     file,
+			Counts:   fs.Counts(),
+			Findings: fs,
+		})
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].File < ret[j].File
+	})
+	return ret
+}
+
+func severityRank(severity string) int {
+	switch severity {
+	case SeverityError:
+		return 0
+	case SeverityWarning:
+		return 1
+	default:
+		return 2 //nolint:gomnd
+	}
+}
+
+// normalizeSeverity converts severities of SARIF and Checkstyle to "error", "warning", or "info".
+func normalizeSeverity(severity string) string {
+	switch strings.ToLower(severity) {
+	case "error", "fatal":
+		return SeverityError
+	case "warning", "warn":
+		return SeverityWarning
+	default:
+		return SeverityInfo
+	}
+}
+
+// normalizePath converts a file path or a file URI to a path relative to wd if possible.
+func normalizePath(p, wd string) string {
+	p = strings.TrimPrefix(p, "file://")
+	if wd == "" || !filepath.IsAbs(p) {
+		return p
+	}
+	if rel, err := filepath.Rel(wd, p); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return p
+}
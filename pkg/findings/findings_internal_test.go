@@ -0,0 +1,56 @@
+package findings
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSARIF(t *testing.T) {
+	t.Parallel()
+	findings, err := ParseSARIF(strings.NewReader(`{
+  "runs": [{
+    "tool": {"driver": {"name": "semgrep", "rules": [{"id": "no-eval", "defaultConfiguration": {"level": "error"}}]}},
+    "results": [
+      {"ruleId": "no-eval", "message": {"text": "eval is dangerous"}, "locations": [{"physicalLocation": {"artifactLocation": {"uri": "file:///src/app/main.js"}, "region": {"startLine": 3, "startColumn": 5}}}]},
+      {"ruleId": "unused", "level": "note", "message": {"text": "unused variable"}, "locations": [{"physicalLocation": {"artifactLocation": {"uri": "lib/a.js"}, "region": {"startLine": 1}}}]}
+    ]
+  }]
+}`), "/src/app")
+	require.Nil(t, err)
+	require.Equal(t, Findings{
+		{File: "main.js", Line: 3, Column: 5, Severity: SeverityError, Rule: "no-eval", Message: "eval is dangerous", Tool: "semgrep"},
+		{File: "lib/a.js", Line: 1, Severity: SeverityInfo, Rule: "unused", Message: "unused variable", Tool: "semgrep"},
+	}, findings)
+}
+
+func TestParseCheckstyle(t *testing.T) {
+	t.Parallel()
+	findings, err := ParseCheckstyle(strings.NewReader(`<?xml version="1.0" encoding="UTF-8"?>
+<checkstyle version="5.0">
+  <file name="main.go">
+    <error line="10" column="2" severity="warning" message="exported function should have comment" source="golint"></error>
+  </file>
+</checkstyle>`), "")
+	require.Nil(t, err)
+	require.Equal(t, Findings{
+		{File: "main.go", Line: 10, Column: 2, Severity: SeverityWarning, Rule: "golint", Message: "exported function should have comment"},
+	}, findings)
+}
+
+func TestFindings_ByFile(t *testing.T) {
+	t.Parallel()
+	findings := Findings{
+		{File: "b.go", Line: 1, Severity: SeverityWarning},
+		{File: "a.go", Line: 5, Severity: SeverityWarning},
+		{File: "a.go", Line: 9, Severity: SeverityError},
+		{File: "a.go", Line: 2, Severity: SeverityWarning},
+	}
+	groups := findings.ByFile()
+	require.Len(t, groups, 2)
+	require.Equal(t, "a.go", groups[0].File)
+	require.Equal(t, &Counts{Error: 1, Warning: 2}, groups[0].Counts)
+	require.Equal(t, Findings{findings[2], findings[3], findings[1]}, groups[0].Findings)
+	require.Equal(t, "b.go", groups[1].File)
+}
@@ -0,0 +1,99 @@
+package findings
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+type sarifLog struct {
+	Runs []*sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool struct {
+		Driver struct {
+			Name  string       `json:"name"`
+			Rules []*sarifRule `json:"rules"`
+		} `json:"driver"`
+	} `json:"tool"`
+	Results []*sarifResult `json:"results"`
+}
+
+type sarifRule struct {
+	ID                   string `json:"id"`
+	DefaultConfiguration struct {
+		Level string `json:"level"`
+	} `json:"defaultConfiguration"`
+}
+
+type sarifResult struct {
+	RuleID    string `json:"ruleId"`
+	RuleIndex *int   `json:"ruleIndex"`
+	Level     string `json:"level"`
+	Message   struct {
+		Text string `json:"text"`
+	} `json:"message"`
+	Locations []struct {
+		PhysicalLocation struct {
+			ArtifactLocation struct {
+				URI string `json:"uri"`
+			} `json:"artifactLocation"`
+			Region struct {
+				StartLine   int `json:"startLine"`
+				StartColumn int `json:"startColumn"`
+			} `json:"region"`
+		} `json:"physicalLocation"`
+	} `json:"locations"`
+}
+
+// ParseSARIF parses a SARIF report.
+// File paths are converted to paths relative to wd if possible.
+func ParseSARIF(r io.Reader, wd string) (Findings, error) {
+	log := &sarifLog{}
+	if err := json.NewDecoder(r).Decode(log); err != nil {
+		return nil, fmt.Errorf("parse a SARIF report as JSON: %w", err)
+	}
+	var findings Findings
+	for _, run := range log.Runs {
+		rules := run.Tool.Driver.Rules
+		for _, result := range run.Results {
+			var rule *sarifRule
+			if result.RuleIndex != nil && *result.RuleIndex >= 0 && *result.RuleIndex < len(rules) {
+				rule = rules[*result.RuleIndex]
+			} else {
+				for _, r := range rules {
+					if r.ID == result.RuleID {
+						rule = r
+						break
+					}
+				}
+			}
+			finding := &Finding{
+				Rule:    result.RuleID,
+				Message: result.Message.Text,
+				Tool:    run.Tool.Driver.Name,
+			}
+			level := result.Level
+			if level == "" && rule != nil {
+				level = rule.DefaultConfiguration.Level
+			}
+			if level == "" {
+				// the default level of SARIF is warning
+				level = SeverityWarning
+			}
+			finding.Severity = normalizeSeverity(level)
+			if finding.Rule == "" && rule != nil {
+				finding.Rule = rule.ID
+			}
+			if len(result.Locations) != 0 {
+				loc := result.Locations[0].PhysicalLocation
+				finding.File = normalizePath(loc.ArtifactLocation.URI, wd)
+				finding.Line = loc.Region.StartLine
+				finding.Column = loc.Region.StartColumn
+			}
+			findings = append(findings, finding)
+		}
+	}
+	return findings, nil
+}
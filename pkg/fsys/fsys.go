@@ -12,5 +12,13 @@ func (*Fsys) Exist(path string) bool {
 }
 
 func (*Fsys) Write(path string, content []byte) error {
-	return os.WriteFile(path, content, 0o755) //nolint:gosec,gomnd,wrapcheck
+	if err := os.WriteFile(path, content, 0o644); err != nil { //nolint:gosec,gomnd
+		return err //nolint:wrapcheck
+	}
+	// os.WriteFile keeps the mode of an existing file
+	return os.Chmod(path, 0o644) //nolint:gosec,gomnd,wrapcheck
+}
+
+func (*Fsys) Read(path string) ([]byte, error) {
+	return os.ReadFile(path) //nolint:wrapcheck
 }
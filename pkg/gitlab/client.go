@@ -8,9 +8,10 @@ import (
 )
 
 type Client struct {
-	note   NoteServices
-	mr     MergeRequestsService
-	commit CommitService
+	note     NoteServices
+	mr       MergeRequestsService
+	commit   CommitService
+	variable ProjectVariablesService
 }
 
 type ParamNew struct {
@@ -40,6 +41,7 @@ func New(param *ParamNew) (*Client, error) {
 	client.note = gl.Notes
 	client.mr = gl.MergeRequests
 	client.commit = gl.Commits
+	client.variable = gl.ProjectVariables
 
 	return client, nil
 }
@@ -67,3 +69,7 @@ type MergeRequestsService interface{}
 type CommitService interface {
 	ListMergeRequestsByCommit(pid interface{}, sha string, options ...gitlab.RequestOptionFunc) ([]*gitlab.MergeRequest, *gitlab.Response, error)
 }
+
+type ProjectVariablesService interface {
+	ListVariables(pid interface{}, opt *gitlab.ListProjectVariablesOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.ProjectVariable, *gitlab.Response, error)
+}
@@ -11,9 +11,16 @@ type Mock struct {
 	Silent   bool
 	Login    string
 	MRNumber int
+	// lastNoteID is used to set a dummy ID to created notes
+	lastNoteID int
 }
 
 func (mock *Mock) CreateComment(note *Note) error {
+	updated := note.ID != 0
+	if !updated {
+		mock.lastNoteID++
+		note.ID = mock.lastNoteID
+	}
 	if mock.Silent {
 		return nil
 	}
@@ -21,6 +28,9 @@ func (mock *Mock) CreateComment(note *Note) error {
 	if note.MRNumber != 0 {
 		msg += " MR:" + strconv.Itoa(note.MRNumber)
 	}
+	if updated {
+		msg += " (update note:" + strconv.Itoa(note.ID) + ")"
+	}
 	fmt.Fprintln(mock.Stderr, msg+"\n[gitlab-comment][DRYRUN] "+note.Body)
 	return nil
 }
@@ -36,3 +46,7 @@ func (mock *Mock) ListNote(mr *MergeRequest) ([]*Note, error) {
 func (mock *Mock) MRNumberWithSHA(owner, repo, sha string) (int, error) {
 	return mock.MRNumber, nil
 }
+
+func (mock *Mock) ListMaskedVariableNames(owner, repo string) ([]string, error) {
+	return nil, nil
+}
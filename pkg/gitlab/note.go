@@ -31,13 +31,16 @@ func (client *Client) sendMRComment(note *Note, body string) error {
 		}
 		return nil
 	}
-	if _, _, err := client.note.CreateMergeRequestNote(
+	n, _, err := client.note.CreateMergeRequestNote(
 		fmt.Sprintf("%s/%s", note.Org, note.Repo),
 		note.MRNumber,
 		&gitlab.CreateMergeRequestNoteOptions{Body: gitlab.String(body)},
-	); err != nil {
+	)
+	if err != nil {
 		return fmt.Errorf("create a note to merge request by GitLab API: %w", err)
 	}
+	// set the created note's ID so that the note can be updated later
+	note.ID = n.ID
 	return nil
 }
 
@@ -0,0 +1,30 @@
+package gitlab
+
+import (
+	"fmt"
+
+	gitlab "github.com/xanzy/go-gitlab"
+)
+
+// ListMaskedVariableNames returns names of the project's CI/CD variables which are masked.
+func (client *Client) ListMaskedVariableNames(owner, repo string) ([]string, error) {
+	var names []string
+	opt := &gitlab.ListProjectVariablesOptions{
+		PerPage: 100, //nolint:gomnd
+	}
+	for {
+		variables, resp, err := client.variable.ListVariables(fmt.Sprintf("%s/%s", owner, repo), opt)
+		if err != nil {
+			return nil, fmt.Errorf("list project variables by GitLab API: %w", err)
+		}
+		for _, variable := range variables {
+			if variable.Masked {
+				names = append(names, variable.Key)
+			}
+		}
+		if resp.NextPage == 0 {
+			return names, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
@@ -0,0 +1,188 @@
+package junit
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	StatusPassed  = "passed"
+	StatusFailed  = "failed"
+	StatusError   = "error"
+	StatusSkipped = "skipped"
+)
+
+// Tests is the summary of JUnit XML reports.
+type Tests struct {
+	Total    int
+	Passed   int
+	Failed   int
+	Errors   int
+	Skipped  int
+	Duration time.Duration
+	Suites   []*Suite
+	// Failures are failed test cases and test cases with errors
+	Failures []*TestCase
+}
+
+type Suite struct {
+	Name     string
+	Total    int
+	Passed   int
+	Failed   int
+	Errors   int
+	Skipped  int
+	Duration time.Duration
+	Cases    []*TestCase
+}
+
+type TestCase struct {
+	Suite     string
+	ClassName string
+	Name      string
+	File      string
+	Status    string
+	Duration  time.Duration
+	// Message is the message of failure, error, or skipped
+	Message string
+	// Output is the body of failure or error and the outputs of the test case
+	Output string
+}
+
+type xmlSuites struct {
+	Suites []*xmlSuite `xml:"testsuite"`
+}
+
+type xmlSuite struct {
+	Name   string         `xml:"name,attr"`
+	Suites []*xmlSuite    `xml:"testsuite"`
+	Cases  []*xmlTestCase `xml:"testcase"`
+}
+
+type xmlResult struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:",chardata"`
+}
+
+type xmlTestCase struct {
+	Name      string     `xml:"name,attr"`
+	ClassName string     `xml:"classname,attr"`
+	File      string     `xml:"file,attr"`
+	Time      string     `xml:"time,attr"`
+	Failure   *xmlResult `xml:"failure"`
+	Error     *xmlResult `xml:"error"`
+	Skipped   *xmlResult `xml:"skipped"`
+	SystemOut string     `xml:"system-out"`
+	SystemErr string     `xml:"system-err"`
+}
+
+// Parse parses a JUnit XML report and adds the result to tests.
+// The root element can be either <testsuites> or <testsuite>.
+func (tests *Tests) Parse(r io.Reader) error {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("read a JUnit XML report: %w", err)
+	}
+	root := struct {
+		XMLName xml.Name
+		xmlSuite
+	}{}
+	if err := xml.Unmarshal(b, &root); err != nil {
+		return fmt.Errorf("parse a JUnit XML report: %w", err)
+	}
+	switch root.XMLName.Local {
+	case "testsuites":
+		for _, suite := range root.Suites {
+			tests.addSuite(suite)
+		}
+	case "testsuite":
+		tests.addSuite(&root.xmlSuite)
+	default:
+		return fmt.Errorf("the root element of a JUnit XML report must be testsuites or testsuite: %s", root.XMLName.Local)
+	}
+	return nil
+}
+
+// addSuite adds a test suite. Nested test suites are flattened.
+func (tests *Tests) addSuite(src *xmlSuite) {
+	for _, child := range src.Suites {
+		tests.addSuite(child)
+	}
+	if len(src.Cases) == 0 {
+		return
+	}
+	suite := &Suite{
+		Name:  src.Name,
+		Cases: make([]*TestCase, len(src.Cases)),
+	}
+	for i, c := range src.Cases {
+		testCase := newTestCase(src.Name, c)
+		suite.Cases[i] = testCase
+		suite.Total++
+		suite.Duration += testCase.Duration
+		switch testCase.Status {
+		case StatusFailed:
+			suite.Failed++
+			tests.Failures = append(tests.Failures, testCase)
+		case StatusError:
+			suite.Errors++
+			tests.Failures = append(tests.Failures, testCase)
+		case StatusSkipped:
+			suite.Skipped++
+		default:
+			suite.Passed++
+		}
+	}
+	tests.Suites = append(tests.Suites, suite)
+	tests.Total += suite.Total
+	tests.Passed += suite.Passed
+	tests.Failed += suite.Failed
+	tests.Errors += suite.Errors
+	tests.Skipped += suite.Skipped
+	tests.Duration += suite.Duration
+}
+
+func newTestCase(suiteName string, src *xmlTestCase) *TestCase {
+	testCase := &TestCase{
+		Suite:     suiteName,
+		ClassName: src.ClassName,
+		Name:      src.Name,
+		File:      src.File,
+		Status:    StatusPassed,
+	}
+	if sec, err := strconv.ParseFloat(strings.TrimSpace(src.Time), 64); err == nil {
+		testCase.Duration = time.Duration(sec * float64(time.Second))
+	}
+	var result *xmlResult
+	switch {
+	case src.Failure != nil:
+		testCase.Status = StatusFailed
+		result = src.Failure
+	case src.Error != nil:
+		testCase.Status = StatusError
+		result = src.Error
+	case src.Skipped != nil:
+		testCase.Status = StatusSkipped
+		testCase.Message = src.Skipped.Message
+		return testCase
+	default:
+		return testCase
+	}
+	testCase.Message = result.Message
+	if testCase.Message == "" {
+		testCase.Message = result.Type
+	}
+	outputs := make([]string, 0, 3) //nolint:gomnd
+	for _, s := range []string{result.Body, src.SystemOut, src.SystemErr} {
+		if s = strings.TrimSpace(s); s != "" {
+			outputs = append(outputs, s)
+		}
+	}
+	testCase.Output = strings.Join(outputs, "\n")
+	return testCase
+}
@@ -0,0 +1,101 @@
+package junit
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTests_Parse(t *testing.T) { //nolint:funlen
+	t.Parallel()
+	data := []struct {
+		title  string
+		inputs []string
+		exp    *Tests
+		isErr  bool
+	}{
+		{
+			title: "testsuites",
+			inputs: []string{`<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="pkg/foo">
+    <testcase classname="foo" name="TestA" time="0.5"></testcase>
+    <testcase classname="foo" name="TestB" time="1.5">
+      <failure message="expected 1">foo_test.go:10: got 2</failure>
+    </testcase>
+    <testcase classname="foo" name="TestC" time="0">
+      <skipped message="not supported"></skipped>
+    </testcase>
+  </testsuite>
+</testsuites>`},
+			exp: &Tests{
+				Total:    3,
+				Passed:   1,
+				Failed:   1,
+				Skipped:  1,
+				Duration: 2 * time.Second,
+			},
+		},
+		{
+			title: "testsuite and multiple reports",
+			inputs: []string{
+				`<testsuite name="a"><testcase name="x" time="1"><error type="panic"/></testcase></testsuite>`,
+				`<testsuite name="b"><testcase name="y" time="1"/></testsuite>`,
+			},
+			exp: &Tests{
+				Total:    2,
+				Passed:   1,
+				Errors:   1,
+				Duration: 2 * time.Second,
+			},
+		},
+		{
+			title:  "invalid root",
+			inputs: []string{`<foo></foo>`},
+			isErr:  true,
+		},
+	}
+	for _, d := range data {
+		d := d
+		t.Run(d.title, func(t *testing.T) {
+			t.Parallel()
+			tests := &Tests{}
+			for _, input := range d.inputs {
+				if err := tests.Parse(strings.NewReader(input)); err != nil {
+					if d.isErr {
+						return
+					}
+					require.Nil(t, err)
+				}
+			}
+			require.False(t, d.isErr)
+			require.Equal(t, d.exp.Total, tests.Total)
+			require.Equal(t, d.exp.Passed, tests.Passed)
+			require.Equal(t, d.exp.Failed, tests.Failed)
+			require.Equal(t, d.exp.Errors, tests.Errors)
+			require.Equal(t, d.exp.Skipped, tests.Skipped)
+			require.Equal(t, d.exp.Duration, tests.Duration)
+			require.Len(t, tests.Failures, d.exp.Failed+d.exp.Errors)
+		})
+	}
+}
+
+func TestTests_Parse_failure(t *testing.T) {
+	t.Parallel()
+	tests := &Tests{}
+	require.Nil(t, tests.Parse(strings.NewReader(`<testsuite name="a">
+<testcase classname="foo" name="TestB"><failure message="expected 1">foo_test.go:10: got 2</failure><system-out>log</system-out></testcase>
+</testsuite>`)))
+	require.Equal(t, []*TestCase{
+		{
+			Suite:     "a",
+			ClassName: "foo",
+			Name:      "TestB",
+			Status:    StatusFailed,
+			Message:   "expected 1",
+			Output:    "foo_test.go:10: got 2\nlog",
+		},
+	}, tests.Failures)
+}
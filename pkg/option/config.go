@@ -0,0 +1,15 @@
+package option
+
+type ConfigWhichOptions struct {
+	ConfigPath string
+}
+
+type ConfigMigrateOptions struct {
+	// Input is the path to github-comment's configuration file.
+	// If it is empty, the file is searched from the working directory
+	Input string
+	// Output is the path to the generated configuration file.
+	// If it is empty, the configuration is written to the standard output
+	Output string
+	Force  bool
+}
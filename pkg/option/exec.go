@@ -1,12 +1,55 @@
 package option
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 type ExecOptions struct {
 	Options
 	Args            []string
 	SkipComment     bool
 	UpdateCondition string
+	// Timeout is the timeout of the command. If it is zero, the timeout in the configuration file is used
+	Timeout time.Duration
+	// GracePeriod is the duration from SIGTERM to SIGKILL when the command times out
+	GracePeriod time.Duration
+	// Shell is a shell script which is run instead of Args
+	Shell string
+	// Batch are commands which are run concurrently. The format is "<name>:<shell script>"
+	Batch       []string
+	Parallelism int
+	BatchFail   string
+	// MaxOutputSize is the maximum size of each captured output in bytes. Negative means unlimited
+	MaxOutputSize int
+	// Live enables to create a note when the command starts and update it with the latest output periodically
+	Live         bool
+	LiveInterval time.Duration
+	// JUnit are glob patterns of JUnit XML reports
+	JUnit []string
+	// SARIF and Checkstyle are glob patterns of linters' reports
+	SARIF      []string
+	Checkstyle []string
+	// Terraform enables to parse the result of terraform plan
+	Terraform bool
+	// TerraformPlanJSON is the path to the output of terraform show -json
+	TerraformPlanJSON string
+	// Dir is the working directory of the command
+	Dir string
+	// StdinFile is the file which is passed to the command's standard input
+	StdinFile string
+	// Env are environment variables which are added to the command
+	Env map[string]string
+	// PTY runs the command under a pseudo-terminal. It is supported only on Linux
+	PTY bool
+	// RetryAttempts is the maximum number of attempts including the first run
+	RetryAttempts int
+	RetryDelay    time.Duration
+	// DontPropagateExitCode makes gitlab-comment exit with 0 even if the command fails
+	DontPropagateExitCode bool
+	// CommentErrorExitCode is the exit code when the command succeeds but posting a comment fails.
+	// If it is 0, the error is only output to the standard error output
+	CommentErrorExitCode int
 }
 
 func ValidateExec(opts *ExecOptions) error {
@@ -16,8 +59,5 @@ func ValidateExec(opts *ExecOptions) error {
 	if opts.TemplateKey == "" {
 		return errors.New("template-key is required")
 	}
-	if len(opts.Args) == 0 {
-		return errors.New("command is required")
-	}
 	return nil
 }
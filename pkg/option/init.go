@@ -0,0 +1,8 @@
+package option
+
+type InitOptions struct {
+	// Preset is comma separated preset names
+	Preset string
+	Output string
+	Force  bool
+}
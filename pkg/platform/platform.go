@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/yuyaban/gitlab-comment/pkg/option"
 )
@@ -99,3 +100,43 @@ func Get() *Platform {
 		PlatformID: "gitlab-ci",
 	}
 }
+
+// CIContext is the context of the CI job.
+// It is used to evaluate conditions of configuration overrides.
+type CIContext struct {
+	Branch         string
+	Tag            string
+	RefName        string
+	DefaultBranch  string
+	PipelineSource string
+	JobName        string
+	Environment    string
+	IsMR           bool
+	MRLabels       []string
+	MRSourceBranch string
+	MRTargetBranch string
+}
+
+func (pt *Platform) CIContext() *CIContext {
+	ciCtx := &CIContext{
+		Branch:         os.Getenv("CI_COMMIT_BRANCH"),
+		Tag:            os.Getenv("CI_COMMIT_TAG"),
+		RefName:        os.Getenv("CI_COMMIT_REF_NAME"),
+		DefaultBranch:  os.Getenv("CI_DEFAULT_BRANCH"),
+		PipelineSource: os.Getenv("CI_PIPELINE_SOURCE"),
+		JobName:        os.Getenv("CI_JOB_NAME"),
+		Environment:    os.Getenv("CI_ENVIRONMENT_NAME"),
+		IsMR:           os.Getenv("CI_MERGE_REQUEST_IID") != "",
+		MRLabels:       []string{},
+		MRSourceBranch: os.Getenv("CI_MERGE_REQUEST_SOURCE_BRANCH_NAME"),
+		MRTargetBranch: os.Getenv("CI_MERGE_REQUEST_TARGET_BRANCH_NAME"),
+	}
+	// CI_COMMIT_BRANCH isn't available in merge request pipelines
+	if ciCtx.Branch == "" {
+		ciCtx.Branch = ciCtx.MRSourceBranch
+	}
+	if labels := os.Getenv("CI_MERGE_REQUEST_LABELS"); labels != "" {
+		ciCtx.MRLabels = strings.Split(labels, ",")
+	}
+	return ciCtx
+}
@@ -0,0 +1,216 @@
+package template
+
+import (
+	"html"
+	"html/template"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ansiPattern matches ANSI CSI escape sequences. SGR sequences end with "m".
+var ansiPattern = regexp.MustCompile("\x1b\\[[0-9;?]*[A-Za-z]")
+
+const (
+	ansiRed         = 1
+	ansiGreen       = 2
+	ansiBrightRed   = 9
+	ansiBrightGreen = 10
+)
+
+// sgrState is the text style set by SGR sequences.
+type sgrState struct {
+	// fg is the index of the 16 basic colors. -1 means the default color or another color
+	fg        int
+	bold      bool
+	italic    bool
+	underline bool
+}
+
+func (state *sgrState) reset() {
+	*state = sgrState{fg: -1}
+}
+
+func (state *sgrState) isRed() bool {
+	return state.fg == ansiRed || state.fg == ansiBrightRed
+}
+
+func (state *sgrState) isGreen() bool {
+	return state.fg == ansiGreen || state.fg == ansiBrightGreen
+}
+
+// apply applies the parameters of a SGR sequence such as "1;31".
+func (state *sgrState) apply(params string) { //nolint:cyclop
+	if params == "" {
+		state.reset()
+		return
+	}
+	codes := strings.Split(params, ";")
+	for i := 0; i < len(codes); i++ {
+		code, err := strconv.Atoi(codes[i])
+		if err != nil {
+			continue
+		}
+		switch {
+		case code == 0:
+			state.reset()
+		case code == 1: //nolint:gomnd
+			state.bold = true
+		case code == 3: //nolint:gomnd
+			state.italic = true
+		case code == 4: //nolint:gomnd
+			state.underline = true
+		case code == 22: //nolint:gomnd
+			state.bold = false
+		case code == 23: //nolint:gomnd
+			state.italic = false
+		case code == 24: //nolint:gomnd
+			state.underline = false
+		case code >= 30 && code <= 37:
+			state.fg = code - 30 //nolint:gomnd
+		case code >= 90 && code <= 97:
+			state.fg = code - 90 + 8 //nolint:gomnd
+		case code == 39: //nolint:gomnd
+			state.fg = -1
+		case code == 38 || code == 48:
+			// extended colors. background colors are ignored
+			i += state.applyExtendedColor(code == 38, codes[i+1:])
+		}
+	}
+}
+
+// applyExtendedColor applies "5;n" (256 colors) or "2;r;g;b" (true color) and returns the number of consumed parameters.
+func (state *sgrState) applyExtendedColor(fg bool, codes []string) int {
+	if len(codes) == 0 {
+		return 0
+	}
+	switch codes[0] {
+	case "5":
+		if len(codes) < 2 { //nolint:gomnd
+			return len(codes)
+		}
+		n, err := strconv.Atoi(codes[1])
+		if fg && err == nil {
+			state.fg = -1
+			if n >= 0 && n < 16 { //nolint:gomnd
+				state.fg = n
+			}
+		}
+		return 2 //nolint:gomnd
+	case "2":
+		if len(codes) < 4 { //nolint:gomnd
+			return len(codes)
+		}
+		if fg {
+			state.fg = -1
+		}
+		return 4 //nolint:gomnd
+	default:
+		return 0
+	}
+}
+
+// ansiSegment is a text with the same style.
+type ansiSegment struct {
+	text  string
+	state sgrState
+}
+
+// parseANSI splits the text into segments by ANSI escape sequences.
+// Escape sequences other than SGR are removed.
+func parseANSI(s string) []ansiSegment {
+	state := sgrState{}
+	state.reset()
+	var segments []ansiSegment
+	last := 0
+	for _, loc := range ansiPattern.FindAllStringIndex(s, -1) {
+		if loc[0] > last {
+			segments = append(segments, ansiSegment{text: s[last:loc[0]], state: state})
+		}
+		last = loc[1]
+		seq := s[loc[0]:loc[1]]
+		if strings.HasSuffix(seq, "m") {
+			state.apply(seq[2 : len(seq)-1])
+		}
+	}
+	if last < len(s) {
+		segments = append(segments, ansiSegment{text: s[last:], state: state})
+	}
+	return segments
+}
+
+// StripANSI removes ANSI escape sequences.
+func StripANSI(s string) string {
+	return ansiPattern.ReplaceAllString(s, "")
+}
+
+// ANSIToHTML converts ANSI SGR sequences to HTML tags which GitLab keeps.
+// The text is HTML escaped, so the result can be embedded in a <pre> tag.
+// Bold, italic, and underline are converted to <b>, <i>, and <ins> tags.
+// Colors are removed because GitLab strips the style attribute. Use ANSIToDiff to highlight colored lines.
+func ANSIToHTML(s string) template.HTML {
+	buf := &strings.Builder{}
+	for _, segment := range parseANSI(s) {
+		var closeTags []string
+		for _, tag := range []struct {
+			enabled bool
+			name    string
+		}{
+			{segment.state.bold, "b"},
+			{segment.state.italic, "i"},
+			{segment.state.underline, "ins"},
+		} {
+			if tag.enabled {
+				buf.WriteString("<" + tag.name + ">")
+				closeTags = append(closeTags, "</"+tag.name+">")
+			}
+		}
+		buf.WriteString(html.EscapeString(segment.text))
+		for i := len(closeTags) - 1; i >= 0; i-- {
+			buf.WriteString(closeTags[i])
+		}
+	}
+	return template.HTML(buf.String()) //nolint:gosec
+}
+
+// ANSIToDiff converts the colored text to the diff format.
+// Lines including red text are prefixed with "-", lines including green text are prefixed with "+",
+// and other lines are prefixed with a space. ANSI escape sequences are removed.
+// The result is expected to be embedded in a "diff" code block so that failures are highlighted.
+func ANSIToDiff(s string) string {
+	buf := &strings.Builder{}
+	line := &strings.Builder{}
+	red, green := false, false
+	flush := func() {
+		switch {
+		case red:
+			buf.WriteString("-")
+		case green:
+			buf.WriteString("+")
+		default:
+			buf.WriteString(" ")
+		}
+		buf.WriteString(line.String())
+		line.Reset()
+		red, green = false, false
+	}
+	for _, segment := range parseANSI(s) {
+		parts := strings.Split(segment.text, "\n")
+		for i, part := range parts {
+			if i > 0 {
+				flush()
+				buf.WriteString("\n")
+			}
+			line.WriteString(part)
+			if strings.TrimSpace(part) == "" {
+				continue
+			}
+			red = red || segment.state.isRed()
+			green = green || segment.state.isGreen()
+		}
+	}
+	if line.Len() != 0 {
+		flush()
+	}
+	return buf.String()
+}
@@ -0,0 +1,77 @@
+package template
+
+import (
+	"html/template"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestANSIToHTML(t *testing.T) {
+	t.Parallel()
+	data := []struct {
+		title string
+		input string
+		exp   template.HTML
+	}{
+		{
+			title: "no color",
+			input: "foo <bar>\n",
+			exp:   "foo &lt;bar&gt;\n",
+		},
+		{
+			title: "red and bold",
+			input: "ok \x1b[1;31mFAIL\x1b[0m done",
+			exp:   `ok <b>FAIL</b> done`,
+		},
+		{
+			title: "true color, underline and cursor movement",
+			input: "\x1b[2K\x1b[38;2;255;0;128;4mpink\x1b[39;24m",
+			exp:   `<ins>pink</ins>`,
+		},
+	}
+	for _, d := range data {
+		d := d
+		t.Run(d.title, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, d.exp, ANSIToHTML(d.input))
+		})
+	}
+}
+
+func TestANSIToDiff(t *testing.T) {
+	t.Parallel()
+	data := []struct {
+		title string
+		input string
+		exp   string
+	}{
+		{
+			title: "no color",
+			input: "foo\nbar\n",
+			exp:   " foo\n bar\n",
+		},
+		{
+			title: "red and green",
+			input: "\x1b[32m+ add\x1b[0m\nkeep\n\x1b[31m- FAIL\nerror\x1b[0m\n",
+			exp:   "++ add\n keep\n-- FAIL\n-error\n",
+		},
+		{
+			title: "256 colors",
+			input: "\x1b[38;5;9mFAIL\x1b[0m\n\x1b[38;5;196mother\x1b[0m\n",
+			exp:   "-FAIL\n other\n",
+		},
+		{
+			title: "colored space isn't highlighted",
+			input: "\x1b[31m \x1b[0mfoo",
+			exp:   "  foo",
+		},
+	}
+	for _, d := range data {
+		d := d
+		t.Run(d.title, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, d.exp, ANSIToDiff(d.input))
+		})
+	}
+}
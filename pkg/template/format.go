@@ -0,0 +1,26 @@
+package template
+
+import (
+	"fmt"
+	"time"
+)
+
+// FormatBytes formats a size in bytes in a human readable form such as "12.3 MiB".
+func FormatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+// FormatElapsed formats a duration from the start of the command such as "01:02.345".
+func FormatElapsed(d time.Duration) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d.%03d", ms/60000, ms/1000%60, ms%1000) //nolint:gomnd
+}
@@ -0,0 +1,36 @@
+package template
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatBytes(t *testing.T) {
+	t.Parallel()
+	data := []struct {
+		size int64
+		exp  string
+	}{
+		{size: 0, exp: "0 B"},
+		{size: 1023, exp: "1023 B"},
+		{size: 1024, exp: "1.0 KiB"},
+		{size: 12897485, exp: "12.3 MiB"},
+		{size: 3 << 30, exp: "3.0 GiB"},
+	}
+	for _, d := range data {
+		d := d
+		t.Run(d.exp, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, d.exp, FormatBytes(d.size))
+		})
+	}
+}
+
+func TestFormatElapsed(t *testing.T) {
+	t.Parallel()
+	require.Equal(t, "00:00.000", FormatElapsed(0))
+	require.Equal(t, "01:02.345", FormatElapsed(time.Minute+2345*time.Millisecond))
+	require.Equal(t, "75:00.000", FormatElapsed(75*time.Minute))
+}
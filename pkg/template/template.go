@@ -57,6 +57,92 @@ func GetTemplates(param *ParamGetTemplates) map[string]string { //nolint:funlen
 		"status":                 `:{{if eq .ExitCode 0}}white_check_mark{{else}}x{{end}}:`,
 		"join_command":           "```\n$ {{.JoinCommand | AvoidHTMLEscape}}\n```",
 		"hidden_combined_output": "<details>\n\n```\n{{.CombinedOutput | AvoidHTMLEscape}}\n```\n\n</details>",
+		"diff_combined_output":   "<details>\n\n```diff\n{{.RawCombinedOutput | ANSIToDiff | AvoidHTMLEscape}}\n```\n\n</details>",
+		"timestamped_output":     "<details>\n\n```diff\n{{range .Output}}{{if eq .Stream \"stderr\"}}-{{else}} {{end}}[{{FormatElapsed .Elapsed}}] {{.Text | AvoidHTMLEscape}}\n{{end}}```\n\n</details>",
+		"timed_out":              `{{if .TimedOut}}:hourglass: The command timed out after {{.Timeout}}{{end}}`,
+		"canceled":               `{{if .Canceled}}:no_entry_sign: The job was canceled and the command was terminated{{if .Signal}} by {{.Signal}}{{end}}{{end}}`,
+		"steps_summary": `| | Name | Exit Code | Duration |
+|---|---|---|---|
+{{range .Steps}}| {{if .Skipped}}:fast_forward:{{else if eq .ExitCode 0}}:white_check_mark:{{else}}:x:{{end}} | {{.Name}}{{if .Dir}} (` + "`{{.Dir}}`" + `){{end}} | {{if .Skipped}}-{{else}}{{.ExitCode}}{{end}} | {{if .Skipped}}-{{else}}{{.Duration.Round 1000000}}{{end}} |
+{{end}}
+{{range .Steps}}{{if not .Skipped}}<details><summary>{{.Name}}</summary>
+
+` + "```" + `
+{{.CombinedOutput | AvoidHTMLEscape}}
+` + "```" + `
+
+</details>
+{{end}}{{end}}`,
+		"junit_summary": `{{if .Tests.Total}}| | Suite | Total | Passed | Failed | Errors | Skipped | Duration |
+|---|---|---|---|---|---|---|---|
+{{range .Tests.Suites}}| {{if or .Failed .Errors}}:x:{{else}}:white_check_mark:{{end}} | {{.Name}} | {{.Total}} | {{.Passed}} | {{.Failed}} | {{.Errors}} | {{.Skipped}} | {{.Duration.Round 1000000}} |
+{{end}}| | **Total** | {{.Tests.Total}} | {{.Tests.Passed}} | {{.Tests.Failed}} | {{.Tests.Errors}} | {{.Tests.Skipped}} | {{.Tests.Duration.Round 1000000}} |
+{{range .Tests.Failures}}
+<details><summary>:x: {{if .ClassName}}{{.ClassName}}.{{end}}{{.Name}}{{if .Message}}: {{.Message}}{{end}}</summary>
+
+` + "```" + `
+{{.Output | AvoidHTMLEscape}}
+` + "```" + `
+
+</details>
+{{end}}{{end}}`,
+		"findings_summary": `{{if .Findings}}| Severity | Count |
+|---|---|
+| :x: error | {{.FindingCounts.Error}} |
+| :warning: warning | {{.FindingCounts.Warning}} |
+| :information_source: info | {{.FindingCounts.Info}} |
+{{range .Findings.ByFile}}
+<details><summary>{{.File}} (error: {{.Counts.Error}}, warning: {{.Counts.Warning}}, info: {{.Counts.Info}})</summary>
+
+| Line | Severity | Rule | Message |
+|---|---|---|---|
+{{range .Findings}}| {{.Line}} | {{.Severity}} | {{.Rule}} | {{.Message | replace "|" "\\|" | replace "\n" " "}} |
+{{end}}
+</details>
+{{end}}{{end}}`,
+		"terraform_plan": `{{if .Terraform.Truncated}}:x: The result of terraform plan isn't parsed because the output exceeds max_output_size
+{{else if not .Terraform.Parsed}}:x: The result of terraform plan isn't found
+{{else if not .Terraform.HasChanges}}:white_check_mark: No changes. Your infrastructure matches the configuration.
+{{else}}{{if .Terraform.HasDestroy}}:warning: **{{add (len .Terraform.Destroy) (len .Terraform.Replace)}} resource(s) will be destroyed or replaced** :warning:
+{{end}}
+| Add | Change | Destroy | Replace |
+|---|---|---|---|
+| {{len .Terraform.Add}} | {{len .Terraform.Change}} | {{len .Terraform.Destroy}} | {{len .Terraform.Replace}} |
+{{range $label, $addresses := dict "Add" .Terraform.Add "Change" .Terraform.Change "Destroy" .Terraform.Destroy "Replace" .Terraform.Replace}}{{if $addresses}}
+<details{{if or (eq $label "Destroy") (eq $label "Replace")}} open{{end}}><summary>{{$label}} ({{len $addresses}})</summary>
+
+{{range $addresses}}* ` + "`{{.}}`" + `
+{{end}}
+</details>
+{{end}}{{end}}{{end}}`,
+		"attempts": `{{if gt (len .Attempts) 1}}{{if eq .ExitCode 0}}:white_check_mark: passed{{else}}:x: failed{{end}} on attempt {{len .Attempts}}/{{.MaxAttempts}}
+{{range initial .Attempts}}
+<details><summary>attempt {{.Number}}: exit code {{.ExitCode}}{{if .TimedOut}} (timed out){{end}}</summary>
+
+` + "```" + `
+{{.CombinedOutput | AvoidHTMLEscape}}
+` + "```" + `
+
+</details>
+{{end}}{{end}}`,
+		"resource_usage": `| Duration | User CPU | System CPU | Max RSS |
+|---|---|---|---|
+| {{.Duration.Round 1000000}} | {{.UserTime.Round 1000000}} | {{.SystemTime.Round 1000000}} | {{if .MaxRSS}}{{FormatBytes .MaxRSS}}{{else}}-{{end}} |{{if .Signal}}
+:skull: The command was terminated by {{.Signal}}{{end}}`,
+		"previous_summary": `{{if .Previous}}Compared with {{if .Previous.SHA1}}{{.Previous.SHA1 | trunc 8}}{{else}}the previous run{{end}}:
+{{if .Previous.Tests}}* tests: {{len .NewFailedTests}} new failure(s), {{len .FixedTests}} fixed
+{{end}}{{if .Previous.Findings}}* findings: {{len .NewFindings}} new, {{.FixedFindingCount}} fixed
+{{end}}{{if .Previous.Duration}}* duration: {{.Duration.Round 1000000000}} ({{printf "%+.0f" .DurationChange | AvoidHTMLEscape}}%)
+{{end}}{{end}}`,
+		"live": `{{if .Running}}:hourglass: Running{{else}}{{template "status" .}} Finished{{end}} {{template "link" .}} ({{.Elapsed.Round 1000000000}})
+{{template "join_command" .}}
+{{if .Tail}}<details{{if .Running}} open{{end}}><summary>Latest output</summary>
+
+` + "```" + `
+{{.Tail | AvoidHTMLEscape}}
+` + "```" + `
+
+</details>{{end}}`,
 	}
 	if strings.Contains(param.JoinCommand, "```") {
 		builtinTemplates["join_command"] = "<pre><code>$ {{.JoinCommand | AvoidHTMLEscape}}</pre></code>"
@@ -108,6 +194,11 @@ func (renderer *Renderer) Render(tpl string, templates map[string]string, params
 	tmpl, err := template.New("comment").Funcs(template.FuncMap{
 		"Env":             renderer.Getenv,
 		"AvoidHTMLEscape": avoidHTMLEscape,
+		"ANSIToHTML":      ANSIToHTML,
+		"ANSIToDiff":      ANSIToDiff,
+		"StripANSI":       StripANSI,
+		"FormatBytes":     FormatBytes,
+		"FormatElapsed":   FormatElapsed,
 	}).Funcs(funcs).Parse(tpl)
 	if err != nil {
 		return "", fmt.Errorf("parse a template: %w", err)
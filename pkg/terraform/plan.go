@@ -0,0 +1,110 @@
+package terraform
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Plan is the summary of a terraform plan.
+type Plan struct {
+	// Parsed is false if the plan result isn't found
+	Parsed bool
+	// Truncated is true if the plan isn't parsed because the middle of the output is omitted by max_output_size
+	Truncated bool
+	// Add, Change, Destroy, and Replace are addresses of resources
+	Add     []string
+	Change  []string
+	Destroy []string
+	Replace []string
+	// HasChanges is true if any resource is added, changed, destroyed, or replaced
+	HasChanges bool
+	// HasDestroy is true if any resource is destroyed or replaced
+	HasDestroy bool
+}
+
+var (
+	resourceLinePattern = regexp.MustCompile(
+		`^\s*# (\S+)(?: \(deposed object \S+\))? (will be created|will be updated in-place|will be destroyed|must be replaced|is tainted, so must be replaced|will be replaced, as requested)`)
+	planLinePattern = regexp.MustCompile(`^\s*Plan: \d+ to (?:import, \d+ to )?add`)
+)
+
+func (plan *Plan) complete() {
+	plan.HasChanges = len(plan.Add)+len(plan.Change)+len(plan.Destroy)+len(plan.Replace) != 0
+	plan.HasDestroy = len(plan.Destroy)+len(plan.Replace) != 0
+}
+
+// ParseText parses the output of terraform plan.
+// The output must not include ANSI escape sequences.
+func ParseText(output string) *Plan {
+	plan := &Plan{}
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024) //nolint:gomnd
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.Contains(line, "No changes.") || planLinePattern.MatchString(line) {
+			plan.Parsed = true
+			continue
+		}
+		m := resourceLinePattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		plan.Parsed = true
+		switch m[2] {
+		case "will be created":
+			plan.Add = append(plan.Add, m[1])
+		case "will be updated in-place":
+			plan.Change = append(plan.Change, m[1])
+		case "will be destroyed":
+			plan.Destroy = append(plan.Destroy, m[1])
+		default:
+			plan.Replace = append(plan.Replace, m[1])
+		}
+	}
+	plan.complete()
+	return plan
+}
+
+type jsonPlan struct {
+	ResourceChanges []struct {
+		Address string `json:"address"`
+		Change  struct {
+			Actions []string `json:"actions"`
+		} `json:"change"`
+	} `json:"resource_changes"`
+}
+
+// ParseJSON parses the output of terraform show -json.
+func ParseJSON(b []byte) (*Plan, error) {
+	src := &jsonPlan{}
+	if err := json.Unmarshal(b, src); err != nil {
+		return nil, fmt.Errorf("parse the output of terraform show -json: %w", err)
+	}
+	plan := &Plan{
+		Parsed: true,
+	}
+	for _, rc := range src.ResourceChanges {
+		actions := strings.Join(rc.Change.Actions, ",")
+		switch actions {
+		case "create":
+			plan.Add = append(plan.Add, rc.Address)
+		case "update":
+			plan.Change = append(plan.Change, rc.Address)
+		case "delete":
+			plan.Destroy = append(plan.Destroy, rc.Address)
+		case "delete,create", "create,delete":
+			plan.Replace = append(plan.Replace, rc.Address)
+		}
+	}
+	plan.complete()
+	return plan, nil
+}
+
+// IsJSON returns true if the output looks like the output of terraform show -json.
+func IsJSON(output string) bool {
+	output = strings.TrimSpace(output)
+	return strings.HasPrefix(output, "{") && strings.Contains(output, `"resource_changes"`)
+}
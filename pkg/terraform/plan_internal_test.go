@@ -0,0 +1,86 @@
+package terraform
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseText(t *testing.T) { //nolint:funlen
+	t.Parallel()
+	data := []struct {
+		title  string
+		output string
+		exp    *Plan
+	}{
+		{
+			title: "changes",
+			output: `Terraform will perform the following actions:
+
+  # aws_instance.a will be created
+  + resource "aws_instance" "a" {
+    }
+
+  # aws_instance.b will be updated in-place
+  # module.m.aws_instance.c must be replaced
+-/+ resource "aws_instance" "c" {
+    }
+
+  # aws_instance.d will be destroyed
+  # (because aws_instance.d is not in configuration)
+  # aws_instance.e (deposed object 1a2b3c) will be destroyed
+  # data.aws_ami.f will be read during apply
+
+Plan: 1 to add, 1 to change, 3 to destroy.
+`,
+			exp: &Plan{
+				Parsed:     true,
+				Add:        []string{"aws_instance.a"},
+				Change:     []string{"aws_instance.b"},
+				Destroy:    []string{"aws_instance.d", "aws_instance.e"},
+				Replace:    []string{"module.m.aws_instance.c"},
+				HasChanges: true,
+				HasDestroy: true,
+			},
+		},
+		{
+			title: "no changes",
+			output: `No changes. Your infrastructure matches the configuration.
+`,
+			exp: &Plan{
+				Parsed: true,
+			},
+		},
+		{
+			title:  "not plan",
+			output: "Error: Invalid reference\n",
+			exp:    &Plan{},
+		},
+	}
+	for _, d := range data {
+		d := d
+		t.Run(d.title, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, d.exp, ParseText(d.output))
+		})
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	t.Parallel()
+	output := `{"format_version":"1.1","resource_changes":[
+{"address":"null_resource.a","change":{"actions":["create"]}},
+{"address":"null_resource.b","change":{"actions":["no-op"]}},
+{"address":"null_resource.c","change":{"actions":["delete","create"]}}
+]}`
+	require.True(t, IsJSON(output))
+	plan, err := ParseJSON([]byte(output))
+	require.Nil(t, err)
+	require.Equal(t, &Plan{
+		Parsed:     true,
+		Add:        []string{"null_resource.a"},
+		Replace:    []string{"null_resource.c"},
+		HasChanges: true,
+		HasDestroy: true,
+	}, plan)
+}